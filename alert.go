@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JoeParrinello/brokerbot/alertlib"
+	"github.com/JoeParrinello/brokerbot/commandlib"
+	"github.com/JoeParrinello/brokerbot/firestorelib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/quotelib"
+)
+
+const (
+	alertRepeatOption = "repeat"
+	alertDMOption     = "dm"
+)
+
+func init() {
+	commandlib.Register(&commandlib.Command{
+		Name:        "alert add",
+		Description: "Create a price alert",
+		Args: []commandlib.Arg{
+			{Name: "ticker", Description: "Ticker to watch, e.g. AAPL or $BTC", Complete: completeTicker},
+			{Name: "condition", Description: "When to alert", Choices: []string{alertlib.Above, alertlib.Below, alertlib.Drop, alertlib.Rise}},
+			{Name: "value", Description: "Price for above/below, percent for drop/rise"},
+			{Name: "options", Description: "\"repeat\" to re-arm after firing, \"dm\" to be alerted privately", Optional: true, Variadic: true},
+		},
+		Usage:    "alert <ticker> <above|below|drop|rise> <price|percent> [repeat] [dm]",
+		Implicit: true,
+		Handler:  handleAlertAdd,
+	})
+	commandlib.Register(&commandlib.Command{
+		Name:        "alert list",
+		Description: "List your price alerts",
+		Handler:     handleAlertList,
+	})
+	commandlib.Register(&commandlib.Command{
+		Name:        "alert delete",
+		Description: "Delete one of your price alerts",
+		Args: []commandlib.Arg{
+			{Name: "id", Description: "Alert ID from alert list"},
+		},
+		Handler: handleAlertDelete,
+	})
+}
+
+func handleAlertAdd(ctx context.Context, r *commandlib.Request) error {
+	condition, ok := alertlib.ParseCondition(r.Args[1])
+	if !ok {
+		return commandlib.ErrUsage
+	}
+	target, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimPrefix(r.Args[2], "$"), "%"), 64)
+	if err != nil || target <= 0 {
+		return commandlib.ErrUsage
+	}
+
+	alert := &firestorelib.Alert{
+		Ticker:    strings.ToUpper(r.Args[0]),
+		Condition: condition,
+		Target:    target,
+		Armed:     true,
+		GuildID:   r.GuildID,
+		ChannelID: r.ChannelID,
+		UserID:    r.UserID,
+		Created:   time.Now(),
+	}
+	for _, option := range r.Args[3:] {
+		switch strings.ToLower(option) {
+		case alertRepeatOption:
+			alert.Repeat = true
+		case alertDMOption:
+			alert.DM = true
+		default:
+			return commandlib.ErrUsage
+		}
+	}
+
+	quote, err := quotelib.GetQuote(ctx, alert.Ticker)
+	if err != nil {
+		return fmt.Errorf("failed to get quote for %q: %v", alert.Ticker, err)
+	}
+	if quote.Value == 0 {
+		return fmt.Errorf("no data for %q", alert.Ticker)
+	}
+	alert.Reference = float64(quote.Value)
+	alert.Currency = quote.Currency
+	if alertlib.Triggered(alert, alert.Reference) {
+		// It would fire on the next check, so it couldn't tell anyone the price got there.
+		messagelib.ReplyMessage(r.Reply, fmt.Sprintf("%s is already at %s, so %s would fire right away.",
+			alert.Ticker, messagelib.FormatPriceIn(quote.Value, quote.Currency), alertlib.Describe(alert)))
+		return nil
+	}
+
+	id, err := firestorelib.CreateAlert(ctx, alert)
+	if err != nil {
+		return err
+	}
+	messagelib.ReplyMessage(r.Reply, fmt.Sprintf("Created alert %s: %s", id, describeAlert(alert)))
+	return nil
+}
+
+func handleAlertList(ctx context.Context, r *commandlib.Request) error {
+	alerts, err := firestorelib.GetAlerts(ctx, r.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get alerts: %v", err)
+	}
+	if len(alerts) == 0 {
+		messagelib.ReplyMessage(r.Reply, "You have no alerts.")
+		return nil
+	}
+	var b strings.Builder
+	for _, alert := range alerts {
+		b.WriteString(fmt.Sprintf("%s: %s\n", alert.ID, describeAlert(alert)))
+	}
+	messagelib.ReplyMessage(r.Reply, b.String())
+	return nil
+}
+
+func handleAlertDelete(ctx context.Context, r *commandlib.Request) error {
+	if err := firestorelib.DeleteAlert(ctx, r.Args[0], r.UserID); err != nil {
+		return fmt.Errorf("failed to delete alert: %v", err)
+	}
+	messagelib.ReplyMessage(r.Reply, fmt.Sprintf("Deleted alert %q", r.Args[0]))
+	return nil
+}
+
+func describeAlert(alert *firestorelib.Alert) string {
+	desc := alertlib.Describe(alert)
+	if alert.Repeat {
+		desc += ", repeating"
+	}
+	if alert.DM {
+		desc += ", via DM"
+	}
+	return desc
+}
@@ -0,0 +1,180 @@
+package alertlib
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/JoeParrinello/brokerbot/firestorelib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/quotelib"
+	"github.com/JoeParrinello/brokerbot/shutdownlib"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	Above = "above"
+	Below = "below"
+	Drop  = "drop"
+	Rise  = "rise"
+)
+
+var (
+	pollInterval = flag.Duration("alertInterval", time.Minute, "How often price alerts are checked.")
+
+	conditionAliases = map[string]string{
+		">":   Above,
+		"<":   Below,
+		Above: Above,
+		Below: Below,
+		Drop:  Drop,
+		Rise:  Rise,
+	}
+)
+
+// ParseCondition returns the canonical condition for user input such as ">" or "drop".
+func ParseCondition(s string) (string, bool) {
+	condition, ok := conditionAliases[strings.ToLower(s)]
+	return condition, ok
+}
+
+// IsPercent reports whether a condition's target is a percentage move from the reference price.
+func IsPercent(condition string) bool {
+	return condition == Drop || condition == Rise
+}
+
+// Triggered reports whether the price satisfies the alert's condition.
+func Triggered(alert *firestorelib.Alert, price float64) bool {
+	switch alert.Condition {
+	case Above:
+		return price >= alert.Target
+	case Below:
+		return price <= alert.Target
+	case Drop:
+		return price <= alert.Reference*(1-alert.Target/100)
+	case Rise:
+		return price >= alert.Reference*(1+alert.Target/100)
+	}
+	return false
+}
+
+// Describe returns a readable summary of an alert's condition, e.g. "AAPL above $200".
+func Describe(alert *firestorelib.Alert) string {
+	if IsPercent(alert.Condition) {
+		return fmt.Sprintf("%s %s %g%% from %s", alert.Ticker, alert.Condition, alert.Target, messagelib.FormatPriceIn(float32(alert.Reference), alert.Currency))
+	}
+	return fmt.Sprintf("%s %s %s", alert.Ticker, alert.Condition, messagelib.FormatPriceIn(float32(alert.Target), alert.Currency))
+}
+
+// Start checks alerts in the background until shutdown, posting to Discord when they fire.
+func Start(ctx context.Context, s *discordgo.Session) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(*pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				checkAlerts(ctx, s)
+			}
+		}
+	}()
+
+	shutdownlib.AddShutdownHandler(func() error {
+		log.Printf("BrokerBot shutting down alert poller.")
+		cancel()
+		<-done
+		return nil
+	})
+}
+
+func checkAlerts(ctx context.Context, s *discordgo.Session) {
+	alerts, err := firestorelib.GetAlerts(ctx, "")
+	if err != nil {
+		log.Printf("failed to get alerts: %v", err)
+		return
+	}
+
+	// Alerts often share tickers, so only quote each one once per check.
+	prices := make(map[string]float64)
+	for _, alert := range alerts {
+		price, ok := prices[alert.Ticker]
+		if !ok {
+			quote, err := quotelib.GetQuote(ctx, alert.Ticker)
+			if err != nil {
+				log.Printf("failed to get quote for alert ticker %q: %v", alert.Ticker, err)
+			} else {
+				price = float64(quote.Value)
+			}
+			prices[alert.Ticker] = price
+		}
+		if price == 0 {
+			// No data for the ticker.
+			continue
+		}
+		checkAlert(ctx, s, alert, price)
+	}
+}
+
+func checkAlert(ctx context.Context, s *discordgo.Session, alert *firestorelib.Alert, price float64) {
+	triggered := Triggered(alert, price)
+	if !alert.Armed {
+		if !triggered {
+			alert.Armed = true
+			if err := firestorelib.UpdateAlert(ctx, alert); err != nil {
+				log.Printf("failed to re-arm alert %q: %v", alert.ID, err)
+			}
+		}
+		return
+	}
+	if !triggered {
+		return
+	}
+
+	sendAlert(s, alert, price)
+
+	var err error
+	switch {
+	case !alert.Repeat:
+		err = firestorelib.DeleteAlert(ctx, alert.ID, alert.UserID)
+	case IsPercent(alert.Condition):
+		// Measure the next move from the price that fired this one.
+		alert.Reference = price
+		err = firestorelib.UpdateAlert(ctx, alert)
+	default:
+		// Wait for the price to cross back before firing again.
+		alert.Armed = false
+		err = firestorelib.UpdateAlert(ctx, alert)
+	}
+	if err != nil {
+		log.Printf("failed to update alert %q after it fired: %v", alert.ID, err)
+	}
+}
+
+func sendAlert(s *discordgo.Session, alert *firestorelib.Alert, price float64) {
+	msg := fmt.Sprintf("Alert: %s (now %s)", Describe(alert), messagelib.FormatPriceIn(float32(price), alert.Currency))
+	log.Printf("Alert %q fired: %s", alert.ID, msg)
+
+	channelID := alert.ChannelID
+	if alert.DM {
+		channel, err := s.UserChannelCreate(alert.UserID)
+		if err != nil {
+			log.Printf("failed to open DM for alert %q, posting to channel instead: %v", alert.ID, err)
+		} else {
+			channelID = channel.ID
+		}
+	}
+	if channelID != alert.ChannelID || alert.GuildID == "" {
+		messagelib.SendMessage(s, channelID, msg)
+		return
+	}
+	messagelib.SendMessage(s, channelID, fmt.Sprintf("<@%s> %s", alert.UserID, msg))
+}
@@ -0,0 +1,84 @@
+package alertlib
+
+import (
+	"testing"
+
+	"github.com/JoeParrinello/brokerbot/firestorelib"
+)
+
+func TestParseCondition(t *testing.T) {
+	tests := []struct {
+		s      string
+		want   string
+		wantOK bool
+	}{
+		{s: ">", want: Above, wantOK: true},
+		{s: "<", want: Below, wantOK: true},
+		{s: "above", want: Above, wantOK: true},
+		{s: "BELOW", want: Below, wantOK: true},
+		{s: "Drop", want: Drop, wantOK: true},
+		{s: "rise", want: Rise, wantOK: true},
+		{s: "=", wantOK: false},
+		{s: ">=", wantOK: false},
+		{s: "", wantOK: false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseCondition(tt.s)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseCondition(%q) = %q, %v, want %q, %v", tt.s, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestTriggered(t *testing.T) {
+	tests := []struct {
+		name  string
+		alert *firestorelib.Alert
+		price float64
+		want  bool
+	}{
+		{name: "above at target", alert: &firestorelib.Alert{Condition: Above, Target: 200}, price: 200, want: true},
+		{name: "above over target", alert: &firestorelib.Alert{Condition: Above, Target: 200}, price: 201, want: true},
+		{name: "above under target", alert: &firestorelib.Alert{Condition: Above, Target: 200}, price: 199.99, want: false},
+		{name: "below at target", alert: &firestorelib.Alert{Condition: Below, Target: 200}, price: 200, want: true},
+		{name: "below under target", alert: &firestorelib.Alert{Condition: Below, Target: 200}, price: 150, want: true},
+		{name: "below over target", alert: &firestorelib.Alert{Condition: Below, Target: 200}, price: 200.01, want: false},
+		{name: "drop reached", alert: &firestorelib.Alert{Condition: Drop, Target: 10, Reference: 100}, price: 90, want: true},
+		{name: "drop not reached", alert: &firestorelib.Alert{Condition: Drop, Target: 10, Reference: 100}, price: 91, want: false},
+		{name: "rise reached", alert: &firestorelib.Alert{Condition: Rise, Target: 5, Reference: 100}, price: 106, want: true},
+		{name: "rise not reached", alert: &firestorelib.Alert{Condition: Rise, Target: 5, Reference: 100}, price: 104, want: false},
+		{name: "rise on a drop", alert: &firestorelib.Alert{Condition: Rise, Target: 5, Reference: 100}, price: 50, want: false},
+		{name: "unknown condition", alert: &firestorelib.Alert{Condition: "sideways", Target: 100}, price: 100, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Triggered(tt.alert, tt.price); got != tt.want {
+				t.Errorf("Triggered(%+v, %g) = %v, want %v", tt.alert, tt.price, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPercent(t *testing.T) {
+	for condition, want := range map[string]bool{Above: false, Below: false, Drop: true, Rise: true} {
+		if got := IsPercent(condition); got != want {
+			t.Errorf("IsPercent(%q) = %v, want %v", condition, got, want)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		alert *firestorelib.Alert
+		want  string
+	}{
+		{alert: &firestorelib.Alert{Ticker: "AAPL", Condition: Above, Target: 200}, want: "AAPL above $200"},
+		{alert: &firestorelib.Alert{Ticker: "EURGBP", Condition: Below, Target: 0.85, Currency: "GBP"}, want: "EURGBP below £0.85"},
+		{alert: &firestorelib.Alert{Ticker: "$BTC", Condition: Drop, Target: 10, Reference: 60000, Currency: "EUR"}, want: "$BTC drop 10% from €60000"},
+	}
+	for _, tt := range tests {
+		if got := Describe(tt.alert); got != tt.want {
+			t.Errorf("Describe(%+v) = %q, want %q", tt.alert, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JoeParrinello/brokerbot/commandlib"
+	"github.com/JoeParrinello/brokerbot/firestorelib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/bwmarrin/discordgo"
+)
+
+func init() {
+	aliasArg := commandlib.Arg{Name: "alias", Description: "Alias name, e.g. ?TECH", Prefix: "?", Complete: completeAlias}
+
+	commandlib.Register(&commandlib.Command{
+		Name:        "alias list",
+		Description: "List all aliases",
+		Handler:     handleAliasList,
+	})
+	commandlib.Register(&commandlib.Command{
+		Name:        "alias get",
+		Description: "Show the tickers in an alias",
+		Args:        []commandlib.Arg{aliasArg},
+		Usage:       "alias get ?<alias>",
+		Handler:     handleAliasGet,
+	})
+	commandlib.Register(&commandlib.Command{
+		Name:        "alias set",
+		Description: "Create an alias for a list of tickers",
+		Args: []commandlib.Arg{
+			aliasArg,
+			{Name: "tickers", Description: "Tickers in the alias, e.g. AAPL MSFT GOOG", Variadic: true, Complete: completeTicker},
+		},
+		Usage:   "alias set ?<alias> <ticker> <ticker> ...",
+		Handler: handleAliasSet,
+	})
+	commandlib.Register(&commandlib.Command{
+		Name:        "alias delete",
+		Description: "Delete an alias",
+		Args:        []commandlib.Arg{aliasArg},
+		Usage:       "alias delete ?<alias>",
+		Handler:     handleAliasDelete,
+	})
+}
+
+func handleAliasList(ctx context.Context, r *commandlib.Request) error {
+	aliases, err := firestorelib.GetAliasesInScope(ctx, r.GuildID)
+	if err != nil {
+		return fmt.Errorf("failed to get alias: %v", err)
+	}
+	sort.Slice(aliases, func(i, j int) bool {
+		return aliases[i].Alias < aliases[j].Alias
+	})
+	var b strings.Builder
+	for _, alias := range aliases {
+		b.WriteString(fmt.Sprintf("%s: %s", alias.Alias, strings.Join(alias.Assets, ", ")))
+		if alias.IsGlobal() && r.GuildID != "" {
+			b.WriteString(" (global)")
+		}
+		b.WriteString("\n")
+	}
+	messagelib.ReplyMessage(r.Reply, b.String())
+	return nil
+}
+
+func handleAliasGet(ctx context.Context, r *commandlib.Request) error {
+	alias, err := firestorelib.GetAlias(ctx, r.GuildID, strings.ToUpper(r.Args[0]))
+	if err != nil {
+		return fmt.Errorf("failed to get alias: %v", err)
+	}
+	messagelib.ReplyMessage(r.Reply, strings.Join(alias.Assets, ", "))
+	return nil
+}
+
+// handleAliasSet creates an alias in the requester's guild, or globally from a DM.
+func handleAliasSet(ctx context.Context, r *commandlib.Request) error {
+	if !strings.HasPrefix(r.Args[0], "?") {
+		return commandlib.ErrUsage
+	}
+	name := strings.ToUpper(r.Args[0])
+
+	alias, err := firestorelib.GetScopedAlias(ctx, r.GuildID, name)
+	if err != nil {
+		return fmt.Errorf("failed to create alias: %v", err)
+	}
+	now := time.Now()
+	if alias == nil {
+		alias = &firestorelib.Alias{
+			Alias:     name,
+			GuildID:   r.GuildID,
+			CreatorID: r.UserID,
+			Created:   now,
+		}
+	} else if !canModifyAlias(r, alias) {
+		return fmt.Errorf("alias %q belongs to another user", name)
+	}
+	alias.Assets = messagelib.CanonicalizeMessage(r.Args[1:])
+	alias.Updated = now
+
+	if err := firestorelib.SetAlias(ctx, alias); err != nil {
+		return fmt.Errorf("failed to create alias: %v", err)
+	}
+	messagelib.ReplyMessage(r.Reply, fmt.Sprintf("Created alias %q", name))
+	return nil
+}
+
+func handleAliasDelete(ctx context.Context, r *commandlib.Request) error {
+	name := strings.ToUpper(r.Args[0])
+
+	alias, err := firestorelib.GetScopedAlias(ctx, r.GuildID, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete alias: %v", err)
+	}
+	if alias == nil {
+		return fmt.Errorf("alias %q not found", name)
+	}
+	if !canModifyAlias(r, alias) {
+		return fmt.Errorf("alias %q belongs to another user", name)
+	}
+
+	if err := firestorelib.DeleteAlias(ctx, alias); err != nil {
+		return fmt.Errorf("failed to delete alias: %v", err)
+	}
+	messagelib.ReplyMessage(r.Reply, fmt.Sprintf("Deleted alias %q", name))
+	return nil
+}
+
+// canModifyAlias allows the alias creator, and members who can manage the alias's guild.
+// Aliases created before ownership was recorded can be modified by anyone.
+func canModifyAlias(r *commandlib.Request, alias *firestorelib.Alias) bool {
+	if alias.CreatorID == "" || alias.CreatorID == r.UserID {
+		return true
+	}
+	if alias.IsGlobal() || alias.GuildID != r.GuildID {
+		return false
+	}
+	return canManageGuild(r)
+}
+
+// canManageGuild reports whether the requester has the Manage Server permission.
+func canManageGuild(r *commandlib.Request) bool {
+	permissions, err := r.Session.UserChannelPermissions(r.UserID, r.ChannelID)
+	if err != nil {
+		log.Printf("failed to get permissions for user %q: %v", r.UserID, err)
+		return false
+	}
+	return permissions&discordgo.PermissionManageServer != 0
+}
+
+// completeAlias suggests aliases visible to the requester's guild starting with partial.
+func completeAlias(ctx context.Context, r *commandlib.Request, partial string) []string {
+	aliases, err := firestorelib.GetAliases(ctx, r.GuildID)
+	if err != nil {
+		log.Printf("failed to get aliases for autocomplete: %v", err)
+		return nil
+	}
+	partial = strings.ToUpper(partial)
+	if !strings.HasPrefix(partial, "?") {
+		partial = "?" + partial
+	}
+	var names []string
+	for alias := range aliases {
+		if strings.HasPrefix(alias, partial) {
+			names = append(names, alias)
+		}
+	}
+	return names
+}
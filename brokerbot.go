@@ -8,15 +8,20 @@ import (
 	"net"
 	"net/http"
 	"os"
-	"sort"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/Finnhub-Stock-API/finnhub-go"
+	"github.com/JoeParrinello/brokerbot/alertlib"
+	"github.com/JoeParrinello/brokerbot/commandlib"
 	"github.com/JoeParrinello/brokerbot/cryptolib"
+	"github.com/JoeParrinello/brokerbot/currencylib"
+	"github.com/JoeParrinello/brokerbot/digestlib"
+	"github.com/JoeParrinello/brokerbot/earningslib"
 	"github.com/JoeParrinello/brokerbot/firestorelib"
+	"github.com/JoeParrinello/brokerbot/forexlib"
 	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/quotelib"
 	"github.com/JoeParrinello/brokerbot/secretlib"
 	"github.com/JoeParrinello/brokerbot/shutdownlib"
 	"github.com/JoeParrinello/brokerbot/statuszlib"
@@ -29,31 +34,25 @@ var (
 	buildVersion string = "dev" // sha1 revision used to build the program
 	buildTime    string = "0"   // when the executable was built
 
-	discordToken = flag.String("t", "", "Discord Token")
-	finnhubToken = flag.String("finnhub", "", "Finnhub Token")
-	testMode     = flag.Bool("test", false, "Run in test mode")
-	fetchCandles = flag.Bool("candles", false, "Fetch candles for single stock requests. Deprecated.")
-fetchStockCandles = flag.Bool("stockCandles", false, "Fetch candles for single stock requests")
-fetchCryptoCandles = flag.Bool("cryptoCandles", true, "Fetch candles for single crypto requests")
+	discordToken       = flag.String("t", "", "Discord Token")
+	finnhubToken       = flag.String("finnhub", "", "Finnhub Token")
+	testMode           = flag.Bool("test", false, "Run in test mode")
+	fetchCandles       = flag.Bool("candles", false, "Fetch candles for single stock requests. Deprecated.")
+	fetchStockCandles  = flag.Bool("stockCandles", false, "Chart single stock requests that don't specify a timeframe")
+	fetchCryptoCandles = flag.Bool("cryptoCandles", true, "Chart single crypto requests that don't specify a timeframe")
+	fetchForexCandles  = flag.Bool("forexCandles", false, "Chart single forex requests that don't specify a timeframe")
+	commandGuildID     = flag.String("commandGuild", "", "Register slash commands in this guild only instead of globally")
 
 	ctx context.Context
 
-	finnhubClient  *finnhub.DefaultApiService
-	geminiClient   *http.Client
-	cloudRunClient *http.Client
+	finnhubClient *finnhub.DefaultApiService
+	cryptoClient  *http.Client
 
 	botPrefixes = []string{"!stonks", "!stnosk", "!stonsk"}
 )
 
-type tickerType int
-
 const (
-	crypto tickerType = iota
-	stock
-
-	aliasToken = "alias"
-	botHandle  = "@BrokerBot"
-	helpToken  = "help"
+	botHandle = "@BrokerBot"
 )
 
 func main() {
@@ -75,15 +74,20 @@ func main() {
 	})
 
 	finnhubClient = finnhub.NewAPIClient(finnhub.NewConfiguration()).DefaultApi
+	currencylib.Init(finnhubClient)
 
-	geminiClient = &http.Client{
+	cryptoClient = &http.Client{
 		Timeout: time.Second * 30,
 	}
-	cryptolib.FetchPriceFeeds(geminiClient)
+	cryptolib.FetchPriceFeeds(cryptoClient)
 
-	cloudRunClient = &http.Client{
-		Timeout: time.Second * 30,
+	quotelib.RegisterProvider(quotelib.Stock, stocklib.NewFinnhubProvider(finnhubClient))
+	cryptoProvider, err := cryptolib.NewProvider(cryptoClient)
+	if err != nil {
+		log.Fatalf("failed to create crypto provider: %v", err)
 	}
+	quotelib.RegisterProvider(quotelib.Crypto, cryptoProvider)
+	quotelib.RegisterProvider(quotelib.Forex, forexlib.NewFinnhubProvider(finnhubClient))
 
 	discordClient, err := discordgo.New("Bot " + *discordToken)
 	if err != nil {
@@ -101,6 +105,7 @@ func main() {
 	discordClient.Client.Timeout = 1 * time.Minute
 
 	discordClient.AddHandler(handleMessage)
+	discordClient.AddHandler(handleInteraction)
 	discordClient.Identify.Intents = discordgo.MakeIntent(discordgo.IntentsGuildMessages | discordgo.IntentsDirectMessages)
 
 	// Open a websocket connection to Discord and begin listening.
@@ -108,6 +113,10 @@ func main() {
 		log.Fatalf("failed to open Discord client: %v", err)
 	}
 
+	if err := registerSlashCommands(discordClient, *commandGuildID); err != nil {
+		log.Printf("failed to register slash commands: %v", err)
+	}
+
 	shutdownlib.AddShutdownHandler(func() error {
 		log.Printf("BrokerBot shutting down connection to Discord.")
 		return discordClient.Close()
@@ -115,6 +124,10 @@ func main() {
 
 	firestorelib.Init()
 
+	alertlib.Start(ctx, discordClient)
+	earningslib.Start(ctx, discordClient, finnhubClient)
+	digestlib.Start(ctx, discordClient)
+
 	http.HandleFunc("/", handleDefaultPort)
 
 	http.HandleFunc("/statusz", statuszlib.HandleStatusz)
@@ -183,193 +196,37 @@ func handleMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
 
 	statuszlib.RecordRequest()
 
-	if len(splitMsg) < 2 || splitMsg[1] == helpToken {
-		// Message didn't have enough parameters.
-		messagelib.SendMessage(s, m.ChannelID, getHelpMessage())
-		return
-	}
-
-	if splitMsg[1] == aliasToken {
-		if len(splitMsg) < 3 {
-			// Message didn't have enough parameters.
-			messagelib.SendMessage(s, m.ChannelID, getHelpMessage())
-			return
-		}
-		switch splitMsg[2] {
-		case "list":
-			if len(splitMsg) < 3 {
-				// Message didn't have enough parameters.
-				messagelib.SendMessage(s, m.ChannelID, getHelpMessage())
-				return
-			}
-			aliases, err := firestorelib.GetAliases(ctx)
-			if err != nil {
-				msg := fmt.Sprintf("failed to get alias: %v", err)
-				log.Println(msg)
-				messagelib.SendMessage(s, m.ChannelID, msg)
-				statuszlib.RecordError()
-				return
-			}
-			var b strings.Builder
-			for alias, assets := range aliases {
-				b.WriteString(fmt.Sprintf("%s: %s\n", alias, strings.Join(assets, ", ")))
-			}
-			messagelib.SendMessage(s, m.ChannelID, b.String())
-			statuszlib.RecordSuccess()
-			return
-		case "get":
-			if len(splitMsg) < 4 {
-				// Message didn't have enough parameters.
-				messagelib.SendMessage(s, m.ChannelID, getHelpMessage())
-				return
-			}
-			alias, err := firestorelib.GetAlias(ctx, strings.ToUpper(splitMsg[3]))
-			if err != nil {
-				msg := fmt.Sprintf("failed to get alias: %v", err)
-				log.Println(msg)
-				messagelib.SendMessage(s, m.ChannelID, msg)
-				statuszlib.RecordError()
-				return
-			}
-			messagelib.SendMessage(s, m.ChannelID, strings.Join(alias, ", "))
-			statuszlib.RecordSuccess()
-			return
-		case "set":
-			if len(splitMsg) < 5 || !strings.HasPrefix(splitMsg[3], "?") {
-				// Message didn't have enough parameters.
-				messagelib.SendMessage(s, m.ChannelID, getHelpMessage())
-				return
-			}
-			if err := firestorelib.CreateAlias(ctx, strings.ToUpper(splitMsg[3]), messagelib.CanonicalizeMessage(splitMsg[4:])); err != nil {
-				msg := fmt.Sprintf("failed to create alias: %v", err)
-				log.Println(msg)
-				messagelib.SendMessage(s, m.ChannelID, msg)
-				statuszlib.RecordError()
-				return
-			}
-			messagelib.SendMessage(s, m.ChannelID, fmt.Sprintf("Created alias %q", strings.ToUpper(splitMsg[3])))
-			statuszlib.RecordSuccess()
-			return
-		case "delete":
-			if len(splitMsg) < 4 {
-				// Message didn't have enough parameters.
-				messagelib.SendMessage(s, m.ChannelID, getHelpMessage())
-				return
-			}
-			if err := firestorelib.DeleteAlias(ctx, strings.ToUpper(splitMsg[3])); err != nil {
-				msg := fmt.Sprintf("failed to delete alias: %v", err)
-				log.Println(msg)
-				messagelib.SendMessage(s, m.ChannelID, msg)
-				statuszlib.RecordError()
-				return
-			}
-			messagelib.SendMessage(s, m.ChannelID, fmt.Sprintf("Deleted alias %q", strings.ToUpper(splitMsg[3])))
-			statuszlib.RecordSuccess()
-			return
-		}
+	reply := &messagelib.ChannelReplier{Session: s, ChannelID: m.ChannelID}
+	if len(splitMsg) < 2 {
 		// Message didn't have enough parameters.
-		messagelib.SendMessage(s, m.ChannelID, getHelpMessage())
+		messagelib.ReplyMessage(reply, getHelpMessage())
 		return
 	}
 
-	var tickers []string = splitMsg[1:]
-	tickers = messagelib.RemoveMentions(tickers)
-	tickers = messagelib.CanonicalizeMessage(tickers)
-
-	var err error
-	tickers, err = messagelib.ExpandAliases(ctx, tickers)
-	if err != nil {
-		msg := fmt.Sprintf("failed to expand aliases: %v", err)
-		log.Println(msg)
-		messagelib.SendMessage(s, m.ChannelID, msg)
-		statuszlib.RecordError()
+	cmd, args, ok := commandlib.Parse(splitMsg[1:])
+	if !ok {
+		messagelib.ReplyMessage(reply, getHelpMessage())
 		return
 	}
-
-	tickers = messagelib.DedupeSlice(tickers)
-
-	startTime := time.Now()
-	log.Printf("Received request for tickers: %s", tickers)
-
-	tickerValueChan := make(chan *messagelib.TickerValue, len(tickers))
-	var wg sync.WaitGroup
-	for _, rawTicker := range tickers {
-		wg.Add(1)
-
-		go func(rawTicker string) {
-			defer wg.Done()
-			ticker, tickerType := getTickerAndType(rawTicker)
-
-			switch tickerType {
-			case stock:
-				tickerValue, err := stocklib.GetQuoteForStockTicker(ctx, finnhubClient, ticker)
-				if err != nil {
-					msg := fmt.Sprintf("Failed to get quote for stock ticker: %q (See logs)", ticker)
-					log.Printf("%s: %v", msg, err)
-					messagelib.SendMessage(s, m.ChannelID, msg)
-					statuszlib.RecordError()
-					return
-				}
-				if *fetchStockCandles && len(tickers) == 1 {
-					chartUrl, err := stocklib.GetCandleGraphForStockAsset(ctx, finnhubClient, cloudRunClient, ticker)
-					if err != nil {
-						msg := fmt.Sprintf("Failed to get graph for stock candles: %q (See logs)", ticker)
-						log.Printf("%s: %v", msg, err)
-						statuszlib.RecordError()
-						tickerValue.ChartUrl = ""
-					} else {
-						tickerValue.ChartUrl = chartUrl
-					}
-				}
-				tickerValueChan <- tickerValue
-			case crypto:
-				tickerValue, err := cryptolib.GetQuoteForCryptoAsset(geminiClient, ticker)
-				if err != nil {
-					msg := fmt.Sprintf("Failed to get quote for crypto ticker: %q (See logs)", ticker)
-					log.Printf("%s: %v", msg, err)
-					messagelib.SendMessage(s, m.ChannelID, msg)
-					statuszlib.RecordError()
-					return
-				}
-				if *fetchCryptoCandles && len(tickers) == 1 {
-					chartUrl, err := cryptolib.GetCandleGraphForCryptoAsset(geminiClient, cloudRunClient, ticker)
-					if err != nil {
-						msg := fmt.Sprintf("Failed to get graph for crypto candles: %q (See logs)", ticker)
-						log.Printf("%s: %v", msg, err)
-						statuszlib.RecordError()
-						tickerValue.ChartUrl = ""
-					} else {
-						tickerValue.ChartUrl = chartUrl
-					}
-				}
-				tickerValueChan <- tickerValue
-			}
-		}(rawTicker)
-	}
-	wg.Wait()
-	close(tickerValueChan)
-
-	var tv []*messagelib.TickerValue
-	for t := range tickerValueChan {
-		tv = append(tv, t)
-	}
-
-	sort.Strings(tickers)
-	sort.SliceStable(tv, func(i, j int) bool {
-		r := strings.Compare(tv[i].Ticker, tv[j].Ticker)
-		return r < 0
+	commandlib.Dispatch(ctx, cmd, &commandlib.Request{
+		Session:   s,
+		ChannelID: m.ChannelID,
+		GuildID:   m.GuildID,
+		UserID:    m.Author.ID,
+		Args:      args,
+		Reply:     reply,
 	})
-
-	messagelib.SendMessageEmbed(s, m.ChannelID, messagelib.CreateMultiMessageEmbed(tv))
-	log.Printf("Sent response for tickers in %v: %s", time.Since(startTime), tickers)
-	statuszlib.RecordSuccess()
 }
 
-func getTickerAndType(s string) (string, tickerType) {
-	if strings.HasPrefix(s, "$") {
-		return strings.TrimPrefix(s, "$"), crypto
-	}
-	return s, stock
+func init() {
+	commandlib.Register(&commandlib.Command{
+		Name:        "help",
+		Description: "Show usage",
+		Handler: func(ctx context.Context, r *commandlib.Request) error {
+			messagelib.ReplyMessage(r.Reply, getHelpMessage())
+			return nil
+		},
+	})
 }
 
 func getHelpMessage() string {
@@ -378,13 +235,12 @@ func getHelpMessage() string {
 		"  @BrokerBot <ticker> <ticker> ...",
 		"  or",
 		"  !stonks <ticker> <ticker> ...",
+		"  or",
+		"  /stonks quote <ticker> <ticker> ...",
+		"Prefix tickers named like a command with # for stocks or $ for crypto, e.g. #NEWS.",
 		"",
-		"Other commands:",
-		"  !stonks help",
-		"  !stonks alias list",
-		"  !stonks alias get ?<alias>",
-		"  !stonks alias set ?<alias> <ticker> <ticker> ...",
-		"  !stonks alias delete ?<alias>",
+		"Commands:",
+		commandlib.GetHelpMessage(botPrefixes[0]),
 	}, "\n")
 }
 
@@ -0,0 +1,186 @@
+package cachelib
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// PriceTTL is how long prices are cached. Prices change constantly, so keep it short.
+	PriceTTL = flag.Duration("priceCacheTTL", 30*time.Second, "How long quote prices are cached.")
+	// NameTTL is how long asset names and other slow changing metadata are cached.
+	NameTTL = flag.Duration("nameCacheTTL", 24*time.Hour, "How long asset names are cached.")
+
+	mu     sync.Mutex
+	caches []*Cache
+)
+
+// Cache is an in-memory TTL cache that shares a single load between concurrent
+// requests for the same key.
+type Cache struct {
+	name string
+	ttl  *time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	calls   map[string]*call
+	// swept is when expired entries were last removed.
+	swept time.Time
+
+	hits   int64
+	misses int64
+	shared int64
+}
+
+type entry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// call is a load in progress.
+type call struct {
+	wg    sync.WaitGroup
+	value interface{}
+	err   error
+}
+
+// Stats is a snapshot of a cache's counters.
+type Stats struct {
+	Name string
+	Size int
+	// Hits were served from the cache.
+	Hits int64
+	// Misses had to be loaded.
+	Misses int64
+	// Shared waited on a load already in progress for another request.
+	Shared int64
+}
+
+// New creates a cache whose entries expire after ttl. The ttl is read on each write,
+// so it may point at a flag that hasn't been parsed yet.
+func New(name string, ttl *time.Duration) *Cache {
+	c := &Cache{
+		name:    name,
+		ttl:     ttl,
+		entries: make(map[string]*entry),
+		calls:   make(map[string]*call),
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	caches = append(caches, c)
+	return c
+}
+
+// Get returns the cached value for key, calling load if it is missing or expired.
+// Errors are not cached, but are shared with callers waiting on the same load. The exception is a
+// load canceled by its caller's context: waiters retry with their own load instead. Only errors
+// that wrap context.Canceled or context.DeadlineExceeded with %w are recognized.
+func (c *Cache) Get(key string, load func() (interface{}, error)) (interface{}, error) {
+	for {
+		c.mu.Lock()
+		if e, ok := c.entries[key]; ok && time.Now().Before(e.expires) {
+			c.mu.Unlock()
+			atomic.AddInt64(&c.hits, 1)
+			return e.value, nil
+		}
+		inflight, ok := c.calls[key]
+		if !ok {
+			inflight = &call{}
+			inflight.wg.Add(1)
+			c.calls[key] = inflight
+			c.mu.Unlock()
+
+			atomic.AddInt64(&c.misses, 1)
+			c.load(key, inflight, load)
+			return inflight.value, inflight.err
+		}
+		c.mu.Unlock()
+
+		atomic.AddInt64(&c.shared, 1)
+		inflight.wg.Wait()
+		if !errors.Is(inflight.err, context.Canceled) && !errors.Is(inflight.err, context.DeadlineExceeded) {
+			return inflight.value, inflight.err
+		}
+	}
+}
+
+// load runs a load for the call in progress, storing its result and releasing any waiters even if it panics.
+func (c *Cache) load(key string, inflight *call, load func() (interface{}, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			inflight.err = fmt.Errorf("cache %q load of %q panicked: %v", c.name, key, r)
+			c.finish(key, inflight)
+			panic(r)
+		}
+	}()
+	inflight.value, inflight.err = load()
+	c.finish(key, inflight)
+}
+
+// finish caches a successful load and releases its waiters. Expired entries are swept out at most
+// once a TTL, so caches of arbitrary keys don't grow without bound.
+func (c *Cache) finish(key string, inflight *call) {
+	c.mu.Lock()
+	// A call that was deleted while loading may have loaded a stale value, so isn't cached.
+	current := c.calls[key] == inflight
+	if current {
+		delete(c.calls, key)
+	}
+	if inflight.err == nil && current {
+		now := time.Now()
+		c.entries[key] = &entry{value: inflight.value, expires: now.Add(*c.ttl)}
+		if now.Sub(c.swept) >= *c.ttl {
+			for k, e := range c.entries {
+				if !now.Before(e.expires) {
+					delete(c.entries, k)
+				}
+			}
+			c.swept = now
+		}
+	}
+	c.mu.Unlock()
+	inflight.wg.Done()
+}
+
+// Delete removes key from the cache, so the next Get loads it again. A load already in progress
+// is still returned to its callers but isn't cached.
+func (c *Cache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+	delete(c.calls, key)
+}
+
+// Stats returns a snapshot of the cache's counters.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	size := len(c.entries)
+	c.mu.Unlock()
+	return Stats{
+		Name:   c.name,
+		Size:   size,
+		Hits:   atomic.LoadInt64(&c.hits),
+		Misses: atomic.LoadInt64(&c.misses),
+		Shared: atomic.LoadInt64(&c.shared),
+	}
+}
+
+// GetStats returns the stats of every cache, sorted by name.
+func GetStats() []Stats {
+	mu.Lock()
+	defer mu.Unlock()
+	stats := make([]Stats, len(caches))
+	for i, c := range caches {
+		stats[i] = c.Stats()
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
@@ -0,0 +1,120 @@
+package cachelib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestGetSweepsExpired(t *testing.T) {
+	ttl := time.Millisecond
+	c := New("test", &ttl)
+	for i := 0; i < 10; i++ {
+		if _, err := c.Get(fmt.Sprint(i), func() (interface{}, error) { return i, nil }); err != nil {
+			t.Fatalf("Get(%d) err = %v", i, err)
+		}
+		time.Sleep(2 * ttl)
+	}
+	if size := c.Stats().Size; size > 2 {
+		t.Errorf("Stats().Size = %d after expiring, want at most 2", size)
+	}
+}
+
+func TestGetPanicReleasesWaiters(t *testing.T) {
+	ttl := time.Minute
+	c := New("test", &ttl)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go func() {
+		defer func() { recover() }()
+		c.Get("key", func() (interface{}, error) {
+			close(started)
+			<-release
+			panic("load failed")
+		})
+	}()
+	<-started
+
+	done := make(chan error)
+	go func() {
+		_, err := c.Get("key", func() (interface{}, error) { return "unused", nil })
+		done <- err
+	}()
+	waitShared(t, c, 1)
+	close(release)
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("waiter err = nil, want the panic")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiter still blocked after the load panicked")
+	}
+}
+
+func TestGetRetriesCanceled(t *testing.T) {
+	ttl := time.Minute
+	c := New("test", &ttl)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go c.Get("key", func() (interface{}, error) {
+		close(started)
+		<-release
+		return nil, fmt.Errorf("failed to get quote: %w", context.Canceled)
+	})
+	<-started
+
+	done := make(chan error)
+	go func() {
+		value, err := c.Get("key", func() (interface{}, error) { return "value", nil })
+		if err == nil && value != "value" {
+			err = errors.New("wrong value")
+		}
+		done <- err
+	}()
+	waitShared(t, c, 1)
+	close(release)
+
+	if err := <-done; err != nil {
+		t.Errorf("waiter err = %v, want it to load the value itself", err)
+	}
+}
+
+func TestDeleteDropsLoadInProgress(t *testing.T) {
+	ttl := time.Minute
+	c := New("test", &ttl)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Get("key", func() (interface{}, error) {
+			close(started)
+			<-release
+			return "stale", nil
+		})
+	}()
+	<-started
+	c.Delete("key")
+	close(release)
+	<-done
+
+	value, err := c.Get("key", func() (interface{}, error) { return "fresh", nil })
+	if err != nil || value != "fresh" {
+		t.Errorf("Get after Delete = %v, %v, want fresh", value, err)
+	}
+}
+
+// waitShared waits until n callers are waiting on loads in progress.
+func waitShared(t *testing.T, c *Cache, n int64) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); c.Stats().Shared < n; {
+		if time.Now().After(deadline) {
+			t.Fatalf("Stats().Shared = %d, want %d", c.Stats().Shared, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,377 @@
+package chartlib
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/JoeParrinello/brokerbot/quotelib"
+)
+
+const (
+	width  = 800
+	height = 400
+
+	marginLeft   = 10
+	marginRight  = 100
+	marginTop    = 15
+	marginBottom = 30
+
+	gridLines = 4
+	// Beyond this many candles the bodies are too thin to read, so draw a line instead.
+	maxCandlesticks = 200
+	// Minimum horizontal space between time axis labels.
+	minTimeLabelSpacing = 100
+)
+
+var (
+	chartTimezone = flag.String("chartTimezone", "America/New_York", "Time zone used for chart time axis labels")
+
+	locationOnce sync.Once
+	location     *time.Location
+)
+
+var (
+	backgroundColor = color.RGBA{0x2F, 0x31, 0x36, 0xFF}
+	gridColor       = color.RGBA{0x40, 0x44, 0x4B, 0xFF}
+	textColor       = color.RGBA{0xB9, 0xBB, 0xBE, 0xFF}
+	upColor         = color.RGBA{0x3B, 0xA5, 0x5C, 0xFF}
+	downColor       = color.RGBA{0xED, 0x42, 0x45, 0xFF}
+)
+
+// canvas maps values onto a plot area within an image.
+type canvas struct {
+	img   *image.RGBA
+	low   float64
+	high  float64
+	count int
+}
+
+// RenderCandles draws candles as a candlestick chart, or as a line chart when there
+// are too many candles to draw individually, and returns it as a PNG. Prices are
+// labeled with "$" when the currency is USD and left bare otherwise, since the chart
+// font has no other currency symbols.
+func RenderCandles(candles []*quotelib.Candle, currency string) ([]byte, error) {
+	if len(candles) < 2 {
+		return nil, errors.New("not enough candles to chart")
+	}
+
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, candle := range candles {
+		low = math.Min(low, float64(candle.Low))
+		high = math.Max(high, float64(candle.High))
+	}
+
+	c := newCanvas(low, high, len(candles))
+	c.drawGrid(func(value float64) string {
+		if currency == "USD" {
+			return "$" + formatPrice(value)
+		}
+		return formatPrice(value)
+	})
+	times := make([]time.Time, len(candles))
+	for i, candle := range candles {
+		times[i] = candle.Time
+	}
+	c.drawTimes(times)
+
+	if len(candles) > maxCandlesticks {
+		col := upColor
+		if candles[len(candles)-1].Close < candles[0].Close {
+			col = downColor
+		}
+		values := make([]float64, len(candles))
+		for i, candle := range candles {
+			values[i] = float64(candle.Close)
+		}
+		c.drawSeries(values, col)
+		return c.encode()
+	}
+
+	bodyWidth := int(float64(plotWidth()) / float64(len(candles)) * 0.7)
+	if bodyWidth < 1 {
+		bodyWidth = 1
+	}
+	for i, candle := range candles {
+		col := upColor
+		if candle.Close < candle.Open {
+			col = downColor
+		}
+		x := c.x(i)
+		wickTop, wickBottom := c.y(float64(candle.High)), c.y(float64(candle.Low))
+		c.fillRect(x, wickTop, 1, wickBottom-wickTop+1, col)
+
+		bodyTop, bodyBottom := c.y(float64(candle.Open)), c.y(float64(candle.Close))
+		if bodyTop > bodyBottom {
+			bodyTop, bodyBottom = bodyBottom, bodyTop
+		}
+		c.fillRect(x-bodyWidth/2, bodyTop, bodyWidth, bodyBottom-bodyTop+1, col)
+	}
+	return c.encode()
+}
+
+func newCanvas(low float64, high float64, count int) *canvas {
+	if high == low {
+		// Flat data would divide by zero, so give it some room.
+		pad := math.Max(math.Abs(high)*0.01, 0.01)
+		low, high = low-pad, high+pad
+	}
+	c := &canvas{
+		img:   image.NewRGBA(image.Rect(0, 0, width, height)),
+		low:   low,
+		high:  high,
+		count: count,
+	}
+	draw.Draw(c.img, c.img.Bounds(), &image.Uniform{backgroundColor}, image.Point{}, draw.Src)
+	return c
+}
+
+func plotWidth() int {
+	return width - marginLeft - marginRight
+}
+
+func plotHeight() int {
+	return height - marginTop - marginBottom
+}
+
+// x returns the horizontal center of the i-th data point.
+func (c *canvas) x(i int) int {
+	return marginLeft + int((float64(i)+0.5)*float64(plotWidth())/float64(c.count))
+}
+
+func (c *canvas) y(value float64) int {
+	return marginTop + int((c.high-value)/(c.high-c.low)*float64(plotHeight()))
+}
+
+// drawGrid draws evenly spaced horizontal grid lines labelled with format.
+func (c *canvas) drawGrid(format func(float64) string) {
+	for i := 0; i <= gridLines; i++ {
+		value := c.low + (c.high-c.low)*float64(i)/gridLines
+		y := c.y(value)
+		c.fillRect(marginLeft, y, plotWidth(), 1, gridColor)
+		c.drawText(width-marginRight+8, y-glyphHeight*glyphScale/2, format(value), textColor)
+	}
+}
+
+// timeLabel groups times into the periods labelled on a time axis.
+type timeLabel struct {
+	period func(t time.Time) int
+	format string
+}
+
+// getTimeLabel picks label periods that suit the time span of a chart.
+func getTimeLabel(span time.Duration) timeLabel {
+	switch {
+	case span <= 2*24*time.Hour:
+		return timeLabel{period: func(t time.Time) int { return t.Hour() }, format: "15:04"}
+	case span <= 120*24*time.Hour:
+		return timeLabel{period: func(t time.Time) int { return t.YearDay() }, format: "1/2"}
+	case span <= 2*365*24*time.Hour:
+		return timeLabel{period: func(t time.Time) int { return int(t.Month()) }, format: "2006-01"}
+	}
+	return timeLabel{period: func(t time.Time) int { return t.Year() }, format: "2006"}
+}
+
+// drawTimes labels the x axis wherever the hour, day, month or year changes, depending on the span of times.
+func (c *canvas) drawTimes(times []time.Time) {
+	label := getTimeLabel(times[len(times)-1].Sub(times[0]))
+	lastLabel := -minTimeLabelSpacing
+	for i := 1; i < len(times); i++ {
+		t, prev := times[i].In(getLocation()), times[i-1].In(getLocation())
+		if label.period(t) == label.period(prev) {
+			continue
+		}
+		x := c.x(i)
+		if x-lastLabel < minTimeLabelSpacing {
+			continue
+		}
+		text := t.Format(label.format)
+		c.fillRect(x, marginTop, 1, plotHeight(), gridColor)
+		c.drawText(x-textWidth(text)/2, height-marginBottom+8, text, textColor)
+		lastLabel = x
+	}
+}
+
+func getLocation() *time.Location {
+	locationOnce.Do(func() {
+		var err error
+		location, err = time.LoadLocation(*chartTimezone)
+		if err != nil {
+			log.Printf("failed to load chart time zone %q, using UTC: %v", *chartTimezone, err)
+			location = time.UTC
+		}
+	})
+	return location
+}
+
+// drawSeries draws values as a connected line.
+func (c *canvas) drawSeries(values []float64, col color.Color) {
+	for i := 1; i < len(values); i++ {
+		c.drawLine(c.x(i-1), c.y(values[i-1]), c.x(i), c.y(values[i]), col)
+	}
+}
+
+// drawLine draws a two pixel wide line using Bresenham's algorithm.
+func (c *canvas) drawLine(x0 int, y0 int, x1 int, y1 int, col color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		c.fillRect(x0, y0, 2, 2, col)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		if e2 := 2 * err; e2 >= dy {
+			err += dy
+			x0 += sx
+		} else {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func (c *canvas) fillRect(x int, y int, w int, h int, col color.Color) {
+	draw.Draw(c.img, image.Rect(x, y, x+w, y+h), &image.Uniform{col}, image.Point{}, draw.Src)
+}
+
+func (c *canvas) encode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.img); err != nil {
+		return nil, fmt.Errorf("failed to encode chart: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// formatPrice keeps price labels short enough to fit in the right margin.
+func formatPrice(value float64) string {
+	switch {
+	case math.Abs(value) >= 10000:
+		return fmt.Sprintf("%.0f", value)
+	case math.Abs(value) >= 100:
+		return fmt.Sprintf("%.1f", value)
+	case math.Abs(value) >= 1:
+		return fmt.Sprintf("%.2f", value)
+	}
+	return fmt.Sprintf("%.4f", value)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// seriesStyle pairs a comparison line color with the emoji square used for it in legends.
+type seriesStyle struct {
+	color color.RGBA
+	emoji string
+}
+
+var seriesStyles = []seriesStyle{
+	{color.RGBA{0x55, 0xAC, 0xEE, 0xFF}, "🟦"},
+	{color.RGBA{0xF4, 0x90, 0x0C, 0xFF}, "🟧"},
+	{color.RGBA{0x78, 0xB1, 0x59, 0xFF}, "🟩"},
+	{color.RGBA{0xAA, 0x8E, 0xD6, 0xFF}, "🟪"},
+	{color.RGBA{0xDD, 0x2E, 0x44, 0xFF}, "🟥"},
+	{color.RGBA{0xFD, 0xCB, 0x58, 0xFF}, "🟨"},
+	{color.RGBA{0xC1, 0x69, 0x4F, 0xFF}, "🟫"},
+	{color.RGBA{0xE6, 0xE7, 0xE8, 0xFF}, "⬜"},
+}
+
+// MaxSeries is the most series RenderComparison can draw with distinct colors.
+var MaxSeries = len(seriesStyles)
+
+// SeriesLegend returns the emoji matching the color of the i-th series drawn by RenderComparison.
+func SeriesLegend(i int) string {
+	return seriesStyles[i%len(seriesStyles)].emoji
+}
+
+// PercentChange returns the percent change from the first close to the last close of candles.
+func PercentChange(candles []*quotelib.Candle) float64 {
+	if len(candles) == 0 || candles[0].Close == 0 {
+		return 0
+	}
+	return (float64(candles[len(candles)-1].Close)/float64(candles[0].Close) - 1) * 100
+}
+
+// RenderComparison draws each series of candles as a line of its percent change since its
+// first candle, overlaid on a shared time axis, and returns it as a PNG.
+func RenderComparison(series [][]*quotelib.Candle) ([]byte, error) {
+	if len(series) > MaxSeries {
+		return nil, fmt.Errorf("too many series to chart: %d, max %d", len(series), MaxSeries)
+	}
+
+	// Merge the timestamps of every series so assets that trade at different hours share an axis.
+	indexes := make(map[int64]int)
+	var times []time.Time
+	for _, candles := range series {
+		for _, candle := range candles {
+			if _, ok := indexes[candle.Time.Unix()]; !ok {
+				indexes[candle.Time.Unix()] = 0
+				times = append(times, candle.Time)
+			}
+		}
+	}
+	if len(times) < 2 {
+		return nil, errors.New("not enough candles to chart")
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+	for i, t := range times {
+		indexes[t.Unix()] = i
+	}
+
+	low, high := 0.0, 0.0
+	changes := make([][]float64, len(series))
+	for i, candles := range series {
+		if len(candles) == 0 || candles[0].Close == 0 {
+			continue
+		}
+		first := float64(candles[0].Close)
+		for _, candle := range candles {
+			change := (float64(candle.Close)/first - 1) * 100
+			changes[i] = append(changes[i], change)
+			low, high = math.Min(low, change), math.Max(high, change)
+		}
+	}
+
+	c := newCanvas(low, high, len(times))
+	c.drawGrid(formatPercent)
+	c.fillRect(marginLeft, c.y(0), plotWidth(), 1, textColor)
+	c.drawTimes(times)
+	for i, candles := range series {
+		for j := 1; j < len(changes[i]); j++ {
+			c.drawLine(
+				c.x(indexes[candles[j-1].Time.Unix()]), c.y(changes[i][j-1]),
+				c.x(indexes[candles[j].Time.Unix()]), c.y(changes[i][j]),
+				seriesStyles[i].color)
+		}
+	}
+	return c.encode()
+}
+
+func formatPercent(value float64) string {
+	if math.Abs(value) >= 100 {
+		return fmt.Sprintf("%+.0f%%", value)
+	}
+	return fmt.Sprintf("%+.1f%%", value)
+}
@@ -0,0 +1,57 @@
+package chartlib
+
+import (
+	"image/color"
+)
+
+const (
+	glyphWidth  = 5
+	glyphHeight = 7
+	glyphScale  = 2
+	// glyphAdvance is the horizontal space taken by each glyph, including spacing.
+	glyphAdvance = (glyphWidth + 1) * glyphScale
+)
+
+// glyphs is a minimal 5x7 bitmap font covering the characters used in axis labels.
+// Each row is a bitmask with the leftmost pixel in the highest bit.
+var glyphs = map[rune][glyphHeight]uint8{
+	'0': {0x0E, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0E},
+	'1': {0x04, 0x0C, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'2': {0x0E, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1F},
+	'3': {0x1F, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0E},
+	'4': {0x02, 0x06, 0x0A, 0x12, 0x1F, 0x02, 0x02},
+	'5': {0x1F, 0x10, 0x1E, 0x01, 0x01, 0x11, 0x0E},
+	'6': {0x06, 0x08, 0x10, 0x1E, 0x11, 0x11, 0x0E},
+	'7': {0x1F, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
+	'8': {0x0E, 0x11, 0x11, 0x0E, 0x11, 0x11, 0x0E},
+	'9': {0x0E, 0x11, 0x11, 0x0F, 0x01, 0x02, 0x0C},
+	'.': {0x00, 0x00, 0x00, 0x00, 0x00, 0x0C, 0x0C},
+	',': {0x00, 0x00, 0x00, 0x00, 0x0C, 0x04, 0x08},
+	'-': {0x00, 0x00, 0x00, 0x1F, 0x00, 0x00, 0x00},
+	'+': {0x00, 0x04, 0x04, 0x1F, 0x04, 0x04, 0x00},
+	'$': {0x04, 0x0F, 0x14, 0x0E, 0x05, 0x1E, 0x04},
+	'%': {0x18, 0x19, 0x02, 0x04, 0x08, 0x13, 0x03},
+	'/': {0x00, 0x01, 0x02, 0x04, 0x08, 0x10, 0x00},
+	':': {0x00, 0x0C, 0x0C, 0x00, 0x0C, 0x0C, 0x00},
+	' ': {},
+}
+
+// drawText draws s with its top left corner at x, y. Characters without a glyph are skipped.
+func (c *canvas) drawText(x int, y int, s string, col color.Color) {
+	for _, r := range s {
+		glyph := glyphs[r]
+		for row := 0; row < glyphHeight; row++ {
+			for column := 0; column < glyphWidth; column++ {
+				if glyph[row]&(1<<(glyphWidth-1-column)) == 0 {
+					continue
+				}
+				c.fillRect(x+column*glyphScale, y+row*glyphScale, glyphScale, glyphScale, col)
+			}
+		}
+		x += glyphAdvance
+	}
+}
+
+func textWidth(s string) int {
+	return len([]rune(s)) * glyphAdvance
+}
@@ -0,0 +1,387 @@
+package commandlib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/statuszlib"
+	"github.com/bwmarrin/discordgo"
+)
+
+// ErrUsage may be returned by a Handler to reply with the command's usage.
+var ErrUsage = errors.New("invalid command usage")
+
+const (
+	// Discord rejects autocomplete responses with more than 25 choices.
+	maxAutocompleteChoices = 25
+)
+
+var (
+	mu       sync.RWMutex
+	commands = make(map[string]*Command)
+	// implicitCommands maps each command group to its Implicit command.
+	implicitCommands = make(map[string]*Command)
+	defaultCommand   string
+)
+
+// Handler runs a command. Returned errors are logged and sent back to the requester.
+type Handler func(ctx context.Context, r *Request) error
+
+// Completer returns suggested values for a partially typed argument. The Request has no Args or Reply.
+type Completer func(ctx context.Context, r *Request, partial string) []string
+
+// Arg describes a single positional argument of a Command.
+type Arg struct {
+	Name        string
+	Description string
+	// Optional args may be omitted, but only after all required args.
+	Optional bool
+	// Variadic args consume all remaining fields and must be last.
+	Variadic bool
+	// Prefix is added to slash command values that don't already start with it, e.g. "?" for aliases.
+	Prefix string
+	// Choices restricts slash command values to a fixed set.
+	Choices []string
+	// Complete enables slash command autocomplete for the arg.
+	Complete Completer
+}
+
+// Command is a single bot command and its argument schema.
+type Command struct {
+	// Name is the space separated path to the command, e.g. "alias set".
+	Name        string
+	Description string
+	Args        []Arg
+	// Usage overrides the usage string generated from Name and Args.
+	Usage string
+	// Implicit allows prefixed messages to omit the last word of Name, e.g. "alert AAPL > 200" for "alert add".
+	// Only one command in a group may be Implicit.
+	Implicit bool
+	Handler  Handler
+}
+
+// Request is a single invocation of a Command, from either a prefixed message or a slash command.
+type Request struct {
+	Session   *discordgo.Session
+	ChannelID string
+	GuildID   string
+	UserID    string
+	Args      []string
+	Reply     messagelib.Replier
+}
+
+// Register adds a command to the registry, replacing any command with the same name.
+// It panics if the command is Implicit and another command in its group already is.
+func Register(cmd *Command) {
+	mu.Lock()
+	defer mu.Unlock()
+	group := getGroup(cmd.Name)
+	if implicit, ok := implicitCommands[group]; ok && implicit.Name == cmd.Name {
+		delete(implicitCommands, group)
+	}
+	if cmd.Implicit {
+		if implicit, ok := implicitCommands[group]; ok {
+			panic(fmt.Sprintf("commands %q and %q are both implicit in group %q", implicit.Name, cmd.Name, group))
+		}
+		implicitCommands[group] = cmd
+	}
+	commands[cmd.Name] = cmd
+}
+
+// getGroup returns the command group of a command name, e.g. "alert" for "alert add".
+func getGroup(name string) string {
+	if i := strings.LastIndex(name, " "); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+// SetDefault sets the command that receives messages that don't match any registered command.
+func SetDefault(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	defaultCommand = name
+}
+
+// GetCommands returns all registered commands sorted by name.
+func GetCommands() []*Command {
+	mu.RLock()
+	defer mu.RUnlock()
+	ret := make([]*Command, 0, len(commands))
+	for _, cmd := range commands {
+		ret = append(ret, cmd)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+func getCommand(name string) (*Command, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	cmd, ok := commands[name]
+	return cmd, ok
+}
+
+// Parse finds the registered command named by the leading fields of a message.
+// Fields that don't name a command are passed as arguments to the default command.
+// Command names take precedence over tickers, so quoting a ticker such as HELP or NEWS
+// needs a ticker prefix, e.g. "#HELP" for the stock or "$HELP" for the crypto.
+// It returns false if the fields name a command group without one of its commands.
+func Parse(fields []string) (*Command, []string, bool) {
+	for n := len(fields); n > 0; n-- {
+		if cmd, ok := getCommand(strings.ToLower(strings.Join(fields[:n], " "))); ok {
+			return cmd, fields[n:], true
+		}
+	}
+	if len(fields) > 0 && isGroup(strings.ToLower(fields[0])) {
+		if cmd, ok := getImplicitCommand(strings.ToLower(fields[0])); ok {
+			return cmd, fields[1:], true
+		}
+		return nil, nil, false
+	}
+	mu.RLock()
+	name := defaultCommand
+	mu.RUnlock()
+	cmd, ok := getCommand(name)
+	return cmd, fields, ok
+}
+
+func isGroup(name string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	for cmdName := range commands {
+		if strings.HasPrefix(cmdName, name+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+func getImplicitCommand(group string) (*Command, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	cmd, ok := implicitCommands[group]
+	return cmd, ok
+}
+
+// Dispatch validates a request's arguments against the command's schema and runs it.
+func Dispatch(ctx context.Context, cmd *Command, r *Request) {
+	if !validArgs(cmd, r.Args) {
+		messagelib.ReplyMessage(r.Reply, fmt.Sprintf("Usage: %s", cmd.GetUsage()))
+		return
+	}
+	if err := cmd.Handler(ctx, r); err != nil {
+		if errors.Is(err, ErrUsage) {
+			messagelib.ReplyMessage(r.Reply, fmt.Sprintf("Usage: %s", cmd.GetUsage()))
+			return
+		}
+		log.Println(err)
+		messagelib.ReplyMessage(r.Reply, err.Error())
+		statuszlib.RecordError()
+		return
+	}
+	statuszlib.RecordSuccess()
+}
+
+// validArgs returns whether there are enough args for the command's required args,
+// and no more than its args can take.
+func validArgs(cmd *Command, args []string) bool {
+	required := 0
+	variadic := false
+	for _, arg := range cmd.Args {
+		if !arg.Optional {
+			required++
+		}
+		variadic = variadic || arg.Variadic
+	}
+	return len(args) >= required && (variadic || len(args) <= len(cmd.Args))
+}
+
+// GetUsage returns the usage string for a command, without the bot prefix.
+func (cmd *Command) GetUsage() string {
+	if cmd.Usage != "" {
+		return cmd.Usage
+	}
+	parts := []string{cmd.Name}
+	for _, arg := range cmd.Args {
+		usage := fmt.Sprintf("<%s>", arg.Name)
+		if arg.Variadic {
+			usage = fmt.Sprintf("%s %s ...", usage, usage)
+		}
+		if arg.Optional {
+			usage = fmt.Sprintf("[%s]", usage)
+		}
+		parts = append(parts, usage)
+	}
+	return strings.Join(parts, " ")
+}
+
+// GetHelpMessage returns the usage of every registered command, each preceded by prefix.
+func GetHelpMessage(prefix string) string {
+	var lines []string
+	for _, cmd := range GetCommands() {
+		lines = append(lines, fmt.Sprintf("  %s %s", prefix, cmd.GetUsage()))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ApplicationCommand builds a slash command with a subcommand (or subcommand group) per registered command.
+func ApplicationCommand(name string, description string) *discordgo.ApplicationCommand {
+	appCmd := &discordgo.ApplicationCommand{
+		Name:        name,
+		Description: description,
+	}
+	groups := make(map[string]*discordgo.ApplicationCommandOption)
+	for _, cmd := range GetCommands() {
+		path := strings.Fields(cmd.Name)
+		sub := &discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        path[len(path)-1],
+			Description: cmd.Description,
+			Options:     argOptions(cmd.Args),
+		}
+		switch len(path) {
+		case 1:
+			appCmd.Options = append(appCmd.Options, sub)
+		case 2:
+			group, ok := groups[path[0]]
+			if !ok {
+				group = &discordgo.ApplicationCommandOption{
+					Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+					Name:        path[0],
+					Description: fmt.Sprintf("%s commands", path[0]),
+				}
+				groups[path[0]] = group
+				appCmd.Options = append(appCmd.Options, group)
+			}
+			group.Options = append(group.Options, sub)
+		default:
+			log.Printf("command %q is nested too deeply to be a slash command", cmd.Name)
+		}
+	}
+	return appCmd
+}
+
+func argOptions(args []Arg) []*discordgo.ApplicationCommandOption {
+	options := make([]*discordgo.ApplicationCommandOption, len(args))
+	for i, arg := range args {
+		options[i] = &discordgo.ApplicationCommandOption{
+			Type:         discordgo.ApplicationCommandOptionString,
+			Name:         arg.Name,
+			Description:  arg.Description,
+			Required:     !arg.Optional,
+			Autocomplete: arg.Complete != nil,
+		}
+		for _, choice := range arg.Choices {
+			options[i].Choices = append(options[i].Choices, &discordgo.ApplicationCommandOptionChoice{Name: choice, Value: choice})
+		}
+	}
+	return options
+}
+
+// ParseInteraction finds the registered command for slash command options and flattens
+// its option values into the same arguments Parse would produce for a prefixed message.
+func ParseInteraction(options []*discordgo.ApplicationCommandInteractionDataOption) (*Command, []string, bool) {
+	cmd, values, ok := findInteractionCommand(options)
+	if !ok {
+		return nil, nil, false
+	}
+	var args []string
+	for _, arg := range cmd.Args {
+		value, ok := values[arg.Name]
+		if !ok {
+			continue
+		}
+		if arg.Variadic {
+			args = append(args, strings.Fields(value)...)
+			continue
+		}
+		if arg.Prefix != "" && !strings.HasPrefix(value, arg.Prefix) {
+			value = arg.Prefix + value
+		}
+		args = append(args, value)
+	}
+	return cmd, args, true
+}
+
+func findInteractionCommand(options []*discordgo.ApplicationCommandInteractionDataOption) (*Command, map[string]string, bool) {
+	var path []string
+	for len(options) == 1 && (options[0].Type == discordgo.ApplicationCommandOptionSubCommandGroup || options[0].Type == discordgo.ApplicationCommandOptionSubCommand) {
+		path = append(path, options[0].Name)
+		options = options[0].Options
+	}
+	cmd, ok := getCommand(strings.Join(path, " "))
+	if !ok {
+		return nil, nil, false
+	}
+	values := make(map[string]string)
+	for _, opt := range options {
+		values[opt.Name] = opt.StringValue()
+	}
+	return cmd, values, true
+}
+
+// Autocomplete returns choices for the focused option of a slash command.
+func Autocomplete(ctx context.Context, r *Request, options []*discordgo.ApplicationCommandInteractionDataOption) []*discordgo.ApplicationCommandOptionChoice {
+	cmd, _, ok := findInteractionCommand(options)
+	if !ok {
+		return nil
+	}
+	focused := focusedOption(options)
+	if focused == nil {
+		return nil
+	}
+	for _, arg := range cmd.Args {
+		if arg.Name != focused.Name || arg.Complete == nil {
+			continue
+		}
+		value := focused.StringValue()
+		if !arg.Variadic {
+			return stringChoices(arg.Complete(ctx, r, value), "")
+		}
+
+		// Complete the last field of a variadic arg, keeping the fields before it.
+		fields := strings.Fields(value)
+		if len(fields) == 0 || strings.HasSuffix(value, " ") {
+			return nil
+		}
+		prefix := strings.Join(fields[:len(fields)-1], " ")
+		if prefix != "" {
+			prefix += " "
+		}
+		return stringChoices(arg.Complete(ctx, r, fields[len(fields)-1]), prefix)
+	}
+	return nil
+}
+
+func focusedOption(options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.ApplicationCommandInteractionDataOption {
+	for _, opt := range options {
+		if opt.Focused {
+			return opt
+		}
+		if focused := focusedOption(opt.Options); focused != nil {
+			return focused
+		}
+	}
+	return nil
+}
+
+func stringChoices(values []string, prefix string) []*discordgo.ApplicationCommandOptionChoice {
+	sort.Strings(values)
+	if len(values) > maxAutocompleteChoices {
+		values = values[:maxAutocompleteChoices]
+	}
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, len(values))
+	for i, v := range values {
+		choices[i] = &discordgo.ApplicationCommandOptionChoice{Name: prefix + v, Value: prefix + v}
+	}
+	return choices
+}
@@ -0,0 +1,118 @@
+package commandlib
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func nopHandler(ctx context.Context, r *Request) error {
+	return nil
+}
+
+// resetCommands clears the registry so each test registers only the commands it needs.
+func resetCommands(t *testing.T) {
+	t.Helper()
+	mu.Lock()
+	defer mu.Unlock()
+	commands = make(map[string]*Command)
+	implicitCommands = make(map[string]*Command)
+	defaultCommand = ""
+}
+
+func TestParse(t *testing.T) {
+	resetCommands(t)
+	Register(&Command{Name: "quote", Handler: nopHandler})
+	Register(&Command{Name: "alert add", Implicit: true, Handler: nopHandler})
+	Register(&Command{Name: "alert list", Handler: nopHandler})
+	Register(&Command{Name: "alias list", Handler: nopHandler})
+	Register(&Command{Name: "alias set", Handler: nopHandler})
+	Register(&Command{Name: "help", Handler: nopHandler})
+	SetDefault("quote")
+
+	tests := []struct {
+		name     string
+		fields   []string
+		wantName string
+		wantArgs []string
+		wantOK   bool
+	}{
+		{name: "command", fields: []string{"quote", "AAPL"}, wantName: "quote", wantArgs: []string{"AAPL"}, wantOK: true},
+		{name: "subcommand", fields: []string{"alias", "list"}, wantName: "alias list", wantArgs: []string{}, wantOK: true},
+		{name: "any case", fields: []string{"ALIAS", "List"}, wantName: "alias list", wantArgs: []string{}, wantOK: true},
+		{name: "subcommand with args", fields: []string{"alias", "set", "?TECH", "AAPL"}, wantName: "alias set", wantArgs: []string{"?TECH", "AAPL"}, wantOK: true},
+		{name: "explicit", fields: []string{"alert", "add", "AAPL", ">", "200"}, wantName: "alert add", wantArgs: []string{"AAPL", ">", "200"}, wantOK: true},
+		{name: "implicit", fields: []string{"alert", "AAPL", ">", "200"}, wantName: "alert add", wantArgs: []string{"AAPL", ">", "200"}, wantOK: true},
+		{name: "implicit subcommand named", fields: []string{"alert", "list"}, wantName: "alert list", wantArgs: []string{}, wantOK: true},
+		{name: "group without subcommand", fields: []string{"alias", "?TECH"}},
+		{name: "bare group", fields: []string{"alias"}},
+		{name: "default", fields: []string{"AAPL", "$BTC"}, wantName: "quote", wantArgs: []string{"AAPL", "$BTC"}, wantOK: true},
+		{name: "ticker named like a command", fields: []string{"HELP"}, wantName: "help", wantArgs: []string{}, wantOK: true},
+		{name: "stock prefix", fields: []string{"#HELP"}, wantName: "quote", wantArgs: []string{"#HELP"}, wantOK: true},
+		{name: "crypto prefix", fields: []string{"$HELP", "AAPL"}, wantName: "quote", wantArgs: []string{"$HELP", "AAPL"}, wantOK: true},
+		{name: "ticker named like a group", fields: []string{"#ALIAS"}, wantName: "quote", wantArgs: []string{"#ALIAS"}, wantOK: true},
+		{name: "empty", fields: []string{}, wantName: "quote", wantArgs: []string{}, wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, args, ok := Parse(tt.fields)
+			if ok != tt.wantOK {
+				t.Fatalf("Parse(%q) ok = %v, want %v", tt.fields, ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if cmd.Name != tt.wantName {
+				t.Errorf("Parse(%q) = %q, want %q", tt.fields, cmd.Name, tt.wantName)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Parse(%q) args = %q, want %q", tt.fields, args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestRegisterImplicit(t *testing.T) {
+	resetCommands(t)
+	Register(&Command{Name: "watch show", Implicit: true, Handler: nopHandler})
+	// Replacing the implicit command is fine.
+	Register(&Command{Name: "watch show", Implicit: true, Handler: nopHandler})
+	Register(&Command{Name: "watch add", Handler: nopHandler})
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Register of a second implicit command in a group didn't panic")
+		}
+	}()
+	Register(&Command{Name: "watch remove", Implicit: true, Handler: nopHandler})
+}
+
+func TestValidArgs(t *testing.T) {
+	fixed := &Command{Name: "options", Args: []Arg{{Name: "ticker"}, {Name: "expiry", Optional: true}}}
+	variadic := &Command{Name: "watch add", Args: []Arg{{Name: "tickers", Variadic: true}}}
+	optionalVariadic := &Command{Name: "alert add", Args: []Arg{{Name: "ticker"}, {Name: "options", Optional: true, Variadic: true}}}
+	none := &Command{Name: "help"}
+
+	tests := []struct {
+		cmd  *Command
+		args []string
+		want bool
+	}{
+		{cmd: fixed, args: nil, want: false},
+		{cmd: fixed, args: []string{"AAPL"}, want: true},
+		{cmd: fixed, args: []string{"AAPL", "DEC"}, want: true},
+		{cmd: fixed, args: []string{"AAPL", "DEC", "extra"}, want: false},
+		{cmd: variadic, args: nil, want: false},
+		{cmd: variadic, args: []string{"AAPL"}, want: true},
+		{cmd: variadic, args: []string{"AAPL", "MSFT", "$BTC"}, want: true},
+		{cmd: optionalVariadic, args: []string{"AAPL"}, want: true},
+		{cmd: optionalVariadic, args: []string{"AAPL", "repeat", "dm"}, want: true},
+		{cmd: none, args: nil, want: true},
+		{cmd: none, args: []string{"extra"}, want: false},
+	}
+	for _, tt := range tests {
+		if got := validArgs(tt.cmd, tt.args); got != tt.want {
+			t.Errorf("validArgs(%q, %q) = %v, want %v", tt.cmd.Name, tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/JoeParrinello/brokerbot/chartlib"
+	"github.com/JoeParrinello/brokerbot/commandlib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/quotelib"
+	"github.com/JoeParrinello/brokerbot/statuszlib"
+)
+
+func init() {
+	commandlib.Register(&commandlib.Command{
+		Name:        "compare",
+		Description: "Chart the percent change of tickers against each other",
+		Args: []commandlib.Arg{
+			{Name: "tickers", Description: "Tickers to compare, e.g. AAPL MSFT $BTC", Variadic: true, Complete: completeTicker},
+			{Name: "timeframe", Description: "Timeframe to compare over", Optional: true, Choices: quotelib.TimeframeNames()},
+		},
+		Usage:   fmt.Sprintf("compare <ticker> <ticker> ... [%s]", strings.Join(quotelib.TimeframeNames(), "|")),
+		Handler: handleCompare,
+	})
+}
+
+// comparedAsset is the candles and display name of one asset in a comparison.
+type comparedAsset struct {
+	ticker  string
+	name    string
+	candles []*quotelib.Candle
+	err     error
+}
+
+func handleCompare(ctx context.Context, r *commandlib.Request) error {
+	tickers, timeframe, _ := splitTimeframe(r.Args)
+	tickers, err := expandTickers(ctx, r, tickers)
+	if err != nil {
+		return err
+	}
+	if len(tickers) < 2 {
+		return commandlib.ErrUsage
+	}
+	if len(tickers) > chartlib.MaxSeries {
+		return fmt.Errorf("can't compare more than %d tickers", chartlib.MaxSeries)
+	}
+
+	// Keep the order of the request so legend colors match what was typed.
+	assets := make([]*comparedAsset, len(tickers))
+	var wg sync.WaitGroup
+	for i, rawTicker := range tickers {
+		assets[i] = &comparedAsset{ticker: rawTicker}
+		wg.Add(1)
+		go func(asset *comparedAsset) {
+			defer wg.Done()
+			asset.name, asset.candles, asset.err = getComparedAsset(ctx, asset.ticker, timeframe)
+		}(assets[i])
+	}
+	wg.Wait()
+
+	var series [][]*quotelib.Candle
+	var values []*messagelib.ComparisonValue
+	var failedTickers []string
+	for _, asset := range assets {
+		if asset.err != nil {
+			log.Printf("Failed to get candles to compare %q: %v", asset.ticker, asset.err)
+			statuszlib.RecordError()
+			failedTickers = append(failedTickers, asset.ticker)
+			continue
+		}
+		values = append(values, &messagelib.ComparisonValue{
+			Ticker: asset.name,
+			Legend: chartlib.SeriesLegend(len(series)),
+			Return: chartlib.PercentChange(asset.candles),
+		})
+		series = append(series, asset.candles)
+	}
+	if len(series) == 0 {
+		return fmt.Errorf("failed to get candles for: %s (See logs)", strings.Join(failedTickers, ", "))
+	}
+
+	chart, err := chartlib.RenderComparison(series)
+	if err != nil {
+		return fmt.Errorf("failed to chart comparison: %v", err)
+	}
+	msg := messagelib.CreateComparisonMessage(values, timeframe.Name, chart)
+	if len(failedTickers) > 0 {
+		msg.Content = fmt.Sprintf("Couldn't get candles for: %s (See logs)", strings.Join(failedTickers, ", "))
+	}
+	messagelib.ReplyMessageComplex(r.Reply, msg)
+	return nil
+}
+
+// getComparedAsset returns the display name and candles over the timeframe for a canonicalized ticker.
+func getComparedAsset(ctx context.Context, rawTicker string, timeframe quotelib.Timeframe) (string, []*quotelib.Candle, error) {
+	ticker, class := quotelib.ParseTicker(rawTicker)
+	provider, ok := quotelib.GetProvider(class)
+	if !ok {
+		return "", nil, fmt.Errorf("no quote provider for %s ticker %q", class, ticker)
+	}
+	candles, err := provider.GetCandles(ctx, ticker, timeframe)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(candles) == 0 {
+		return "", nil, fmt.Errorf("no candles for %s ticker %q", class, ticker)
+	}
+	name, err := provider.GetName(ctx, ticker)
+	if err != nil || name == "" {
+		return rawTicker, candles, nil
+	}
+	return fmt.Sprintf("%s (%s)", rawTicker, name), candles, nil
+}
@@ -0,0 +1,129 @@
+package cryptolib
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/JoeParrinello/brokerbot/quotelib"
+)
+
+const (
+	coinbaseBaseURL                = "https://api.exchange.coinbase.com"
+	coinbaseStatsURIFormatString   = "/products/%s-%s/stats"
+	coinbaseCandlesURIFormatString = "/products/%s-%s/candles"
+	// coinbaseMaxCandles is the most candles Coinbase returns per request.
+	coinbaseMaxCandles = 300
+)
+
+// coinbaseGranularities is the Coinbase candle granularity used for each chart timeframe.
+var coinbaseGranularities = map[quotelib.Timeframe]time.Duration{
+	quotelib.OneDay:    5 * time.Minute,
+	quotelib.FiveDays:  time.Hour,
+	quotelib.OneMonth:  6 * time.Hour,
+	quotelib.SixMonths: 24 * time.Hour,
+	quotelib.OneYear:   24 * time.Hour,
+	quotelib.FiveYears: 24 * time.Hour,
+}
+
+// coinbaseStats is the response of Coinbase's product stats endpoint, covering the last 24 hours.
+type coinbaseStats struct {
+	Open string `json:"open"`
+	High string `json:"high"`
+	Low  string `json:"low"`
+	Last string `json:"last"`
+}
+
+// coinbaseSource quotes crypto assets from the Coinbase Exchange public API.
+type coinbaseSource struct {
+	client *http.Client
+}
+
+func newCoinbaseSource(client *http.Client) source {
+	return &coinbaseSource{client: client}
+}
+
+func (s *coinbaseSource) name() string {
+	return "Coinbase"
+}
+
+func (s *coinbaseSource) getQuote(ctx context.Context, asset string, currency string) (*quote, error) {
+	stats, err := s.fetchStats(ctx, asset, currency)
+	if err != nil {
+		return nil, err
+	}
+	q := &quote{price: parsePrice(stats.Last)}
+	if open := parsePrice(stats.Open); open != 0 {
+		q.change = (q.price - open) / open * 100
+	}
+	return q, nil
+}
+
+func (s *coinbaseSource) getRange(ctx context.Context, asset string, currency string) (*priceRange, error) {
+	stats, err := s.fetchStats(ctx, asset, currency)
+	if err != nil {
+		return nil, err
+	}
+	return &priceRange{open: parsePrice(stats.Open), high: parsePrice(stats.High), low: parsePrice(stats.Low)}, nil
+}
+
+func (s *coinbaseSource) fetchStats(ctx context.Context, asset string, currency string) (*coinbaseStats, error) {
+	var stats coinbaseStats
+	if err := getJSON(ctx, s.client, coinbaseBaseURL+fmt.Sprintf(coinbaseStatsURIFormatString, asset, currency), &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// getCandles pages through the timeframe, since Coinbase limits how many candles a request returns.
+func (s *coinbaseSource) getCandles(ctx context.Context, asset string, currency string, timeframe quotelib.Timeframe) ([]*quotelib.Candle, error) {
+	granularity, ok := coinbaseGranularities[timeframe]
+	if !ok {
+		return nil, fmt.Errorf("unsupported crypto timeframe: %q", timeframe.Name)
+	}
+
+	var candles []*quotelib.Candle
+	end := time.Now()
+	start := end.Add(-timeframe.Duration)
+	for end.After(start) {
+		pageStart := end.Add(-coinbaseMaxCandles * granularity)
+		if pageStart.Before(start) {
+			pageStart = start
+		}
+		query := url.Values{}
+		query.Set("granularity", fmt.Sprint(int(granularity.Seconds())))
+		query.Set("start", pageStart.UTC().Format(time.RFC3339))
+		query.Set("end", end.UTC().Format(time.RFC3339))
+
+		// Coinbase candles are [time, low, high, open, close, volume] arrays, newest first.
+		var rows [][]float64
+		if err := getJSON(ctx, s.client, coinbaseBaseURL+fmt.Sprintf(coinbaseCandlesURIFormatString, asset, currency)+"?"+query.Encode(), &rows); err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			// Nothing traded before this, e.g. the pair was listed recently.
+			break
+		}
+		page := make([]*quotelib.Candle, 0, len(rows))
+		for i := len(rows) - 1; i >= 0; i-- {
+			row := rows[i]
+			if len(row) < 6 {
+				continue
+			}
+			page = append(page, &quotelib.Candle{
+				Time:   time.Unix(int64(row[0]), 0),
+				Low:    float32(row[1]),
+				High:   float32(row[2]),
+				Open:   float32(row[3]),
+				Close:  float32(row[4]),
+				Volume: float32(row[5]),
+			})
+		}
+		candles = append(page, candles...)
+		// Both ends of a page are inclusive, so step back to not fetch the boundary candle twice.
+		end = pageStart.Add(-time.Second)
+	}
+	return candles, nil
+}
@@ -0,0 +1,154 @@
+package cryptolib
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/JoeParrinello/brokerbot/quotelib"
+)
+
+const (
+	coinGeckoBaseURL             = "https://api.coingecko.com/api/v3"
+	coinGeckoMarketsURI          = "/coins/markets"
+	coinGeckoPriceURI            = "/simple/price"
+	coinGeckoOHLCURIFormatString = "/coins/%s/ohlc"
+	coinGeckoCoinURIFormatString = "/coins/%s"
+	// coinGeckoMarketsPerPage is how many of the largest coins by market cap can be quoted.
+	// Tickers are ambiguous across CoinGecko's long tail, so smaller coins aren't looked up.
+	coinGeckoMarketsPerPage = 250
+)
+
+var (
+	// coinGeckoDays is the CoinGecko OHLC range, in days, fetched for each chart timeframe.
+	// CoinGecko only accepts a few ranges, so the candles are trimmed to the timeframe.
+	coinGeckoDays = map[quotelib.Timeframe]string{
+		quotelib.OneDay:    "1",
+		quotelib.FiveDays:  "7",
+		quotelib.OneMonth:  "30",
+		quotelib.SixMonths: "180",
+		quotelib.OneYear:   "365",
+		quotelib.FiveYears: "max",
+	}
+)
+
+// coinGeckoMarket is a coin in CoinGecko's markets response.
+type coinGeckoMarket struct {
+	ID     string `json:"id"`
+	Symbol string `json:"symbol"`
+	Name   string `json:"name"`
+	Image  string `json:"image"`
+}
+
+// coinGeckoSource quotes crypto assets from the CoinGecko public API, which aggregates
+// prices across exchanges and so covers coins no single exchange lists.
+type coinGeckoSource struct {
+	client *http.Client
+}
+
+func newCoinGeckoSource(client *http.Client) source {
+	return &coinGeckoSource{client: client}
+}
+
+func (s *coinGeckoSource) name() string {
+	return "CoinGecko"
+}
+
+func (s *coinGeckoSource) getQuote(ctx context.Context, asset string, currency string) (*quote, error) {
+	id, err := s.getID(ctx, asset)
+	if err != nil {
+		return nil, err
+	}
+	vsCurrency := strings.ToLower(currency)
+	query := url.Values{}
+	query.Set("ids", id)
+	query.Set("vs_currencies", vsCurrency)
+	query.Set("include_24hr_change", "true")
+
+	var prices map[string]map[string]float64
+	if err := getJSON(ctx, s.client, coinGeckoBaseURL+coinGeckoPriceURI+"?"+query.Encode(), &prices); err != nil {
+		return nil, err
+	}
+	// Currencies CoinGecko doesn't price in are left out of the response.
+	price, ok := prices[id][vsCurrency]
+	if !ok {
+		return nil, errNoPair
+	}
+	return &quote{price: float32(price), change: float32(prices[id][vsCurrency+"_24h_change"])}, nil
+}
+
+func (s *coinGeckoSource) getCandles(ctx context.Context, asset string, currency string, timeframe quotelib.Timeframe) ([]*quotelib.Candle, error) {
+	days, ok := coinGeckoDays[timeframe]
+	if !ok {
+		return nil, fmt.Errorf("unsupported crypto timeframe: %q", timeframe.Name)
+	}
+	id, err := s.getID(ctx, asset)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	query.Set("vs_currency", strings.ToLower(currency))
+	query.Set("days", days)
+
+	// CoinGecko candles are [time, open, high, low, close] arrays, oldest first, without volume.
+	var rows [][]float64
+	if err := getJSON(ctx, s.client, coinGeckoBaseURL+fmt.Sprintf(coinGeckoOHLCURIFormatString, id)+"?"+query.Encode(), &rows); err != nil {
+		return nil, err
+	}
+	start := time.Now().Add(-timeframe.Duration)
+	candles := make([]*quotelib.Candle, 0, len(rows))
+	for _, row := range rows {
+		if len(row) < 5 || time.UnixMilli(int64(row[0])).Before(start) {
+			continue
+		}
+		candles = append(candles, &quotelib.Candle{
+			Time:  time.UnixMilli(int64(row[0])),
+			Open:  float32(row[1]),
+			High:  float32(row[2]),
+			Low:   float32(row[3]),
+			Close: float32(row[4]),
+		})
+	}
+	return candles, nil
+}
+
+// getID returns CoinGecko's ID for an asset, e.g. "bitcoin" for "BTC".
+func (s *coinGeckoSource) getID(ctx context.Context, asset string) (string, error) {
+	id := getAssetInfo(ctx, s.client, asset).CoinGeckoID
+	if id == "" {
+		return "", errNoPair
+	}
+	return id, nil
+}
+
+// fetchCoinGeckoMarkets returns the largest coins by market cap, largest first.
+func fetchCoinGeckoMarkets(ctx context.Context, client *http.Client) ([]*coinGeckoMarket, error) {
+	query := url.Values{}
+	query.Set("vs_currency", "usd")
+	query.Set("order", "market_cap_desc")
+	query.Set("per_page", fmt.Sprint(coinGeckoMarketsPerPage))
+
+	var markets []*coinGeckoMarket
+	if err := getJSON(ctx, client, coinGeckoBaseURL+coinGeckoMarketsURI+"?"+query.Encode(), &markets); err != nil {
+		return nil, fmt.Errorf("failed to get CoinGecko markets: %v", err)
+	}
+	return markets, nil
+}
+
+// fetchCoinGeckoCategories returns the categories of a coin, e.g. "Smart Contract Platform".
+func fetchCoinGeckoCategories(ctx context.Context, client *http.Client, id string) ([]string, error) {
+	query := url.Values{}
+	for _, data := range []string{"localization", "tickers", "market_data", "community_data", "developer_data"} {
+		query.Set(data, "false")
+	}
+	var coin struct {
+		Categories []string `json:"categories"`
+	}
+	if err := getJSON(ctx, client, coinGeckoBaseURL+fmt.Sprintf(coinGeckoCoinURIFormatString, id)+"?"+query.Encode(), &coin); err != nil {
+		return nil, fmt.Errorf("failed to get CoinGecko coin %q: %v", id, err)
+	}
+	return coin.Categories, nil
+}
@@ -1,20 +1,19 @@
 package cryptolib
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
-	"os"
 	"strconv"
 	"sync"
 	"time"
 
-	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/cachelib"
+	"github.com/JoeParrinello/brokerbot/quotelib"
 )
 
 var (
@@ -23,31 +22,36 @@ var (
 	lastUpdated time.Time
 
 	priceFeedAgeLimit = flag.Duration("priceFeedAgeLimit", 5*time.Minute, "The maximum age limit of crypto price feeds before we re-fetch them.")
+	priceFeedCache    = cachelib.New("crypto price feed", priceFeedAgeLimit)
 
-	cryptoNames = map[string]string{
-		"BTC":  "Bitcoin",
-		"LTC":  "Litecoin",
-		"ETH":  "Ethereum",
-		"DOGE": "Dogecoin",
-		"XRP":  "Ripple",
-		"BCH":  "Bitcoin Cash",
-		"USDT": "Tether",
-		"ZEC":  "Zcash",
-		"LINK": "Chainlink",
-		"DOT":  "Polkadot",
-		"XMR":  "Monero",
-		"LUNA": "Terra",
-		"DASH": "Dash",
+	// geminiCandleIntervals is the Gemini candle interval used for each chart timeframe.
+	geminiCandleIntervals = map[quotelib.Timeframe]string{
+		quotelib.OneDay:    "5m",
+		quotelib.FiveDays:  "30m",
+		quotelib.OneMonth:  "6hr",
+		quotelib.SixMonths: "1day",
+		quotelib.OneYear:   "1day",
+		quotelib.FiveYears: "1day",
 	}
 )
 
 const (
 	geminiBaseURL                = "https://api.gemini.com"
 	geminiPriceFeedURI           = "/v1/pricefeed"
-	geminiCandlesURIFormatString = "/v2/candles/%s/15m"
+	geminiCandlesURIFormatString = "/v2/candles/%s/%s"
+	geminiTickerURIFormatString  = "/v2/ticker/%s"
 	brokerbotUserAgent           = "brokerbot"
 )
 
+// geminiTicker is the response of Gemini's v2 ticker endpoint, covering the last 24 hours.
+type geminiTicker struct {
+	Symbol string `json:"symbol"`
+	Open   string `json:"open"`
+	High   string `json:"high"`
+	Low    string `json:"low"`
+	Close  string `json:"close"`
+}
+
 // PriceFeed is a current Gemini provided ticker value.
 type PriceFeed struct {
 	Pair   string `json:"pair"`
@@ -55,65 +59,118 @@ type PriceFeed struct {
 	Change string `json:"percentChange24h"`
 }
 
-// GetQuoteForCryptoAsset returns the TickerValue for Crypto Ticker.
-func GetQuoteForCryptoAsset(geminiClient *http.Client, asset string) (*messagelib.TickerValue, error) {
-	formattedAsset := asset + "USD"
-	priceFeed, ok := getFeedForAsset(geminiClient, formattedAsset)
+// geminiSource quotes crypto assets from Gemini's price feed, which covers every pair Gemini lists.
+type geminiSource struct {
+	geminiClient *http.Client
+}
+
+func newGeminiSource(geminiClient *http.Client) source {
+	return &geminiSource{geminiClient: geminiClient}
+}
+
+func (s *geminiSource) name() string {
+	return "Gemini"
+}
+
+// getQuote uses Gemini pairs in any currency Gemini lists, e.g. ETHBTC or BTCGUSD.
+func (s *geminiSource) getQuote(ctx context.Context, asset string, currency string) (*quote, error) {
+	priceFeed, ok := getFeedForAsset(s.geminiClient, asset+currency)
 	if !ok {
-		return &messagelib.TickerValue{Ticker: assetWithName(asset), Value: 0.0, Change: 0.0}, nil
+		return nil, errNoPair
 	}
-
 	price, err := strconv.ParseFloat(priceFeed.Price, 32)
 	if err != nil {
-		return &messagelib.TickerValue{Ticker: assetWithName(asset), Value: 0.0, Change: 0.0}, nil
+		return nil, fmt.Errorf("invalid Gemini price %q for %s: %v", priceFeed.Price, priceFeed.Pair, err)
 	}
 	change, err := strconv.ParseFloat(priceFeed.Change, 32)
 	if err != nil {
-		return &messagelib.TickerValue{Ticker: assetWithName(asset), Value: float32(price), Change: 0.0}, nil
+		return &quote{price: float32(price)}, nil
+	}
+	return &quote{price: float32(price), change: float32(change) * 100.0}, nil
+}
+
+func (s *geminiSource) getCandles(ctx context.Context, asset string, currency string, timeframe quotelib.Timeframe) ([]*quotelib.Candle, error) {
+	if _, ok := getFeedForAsset(s.geminiClient, asset+currency); !ok {
+		return nil, errNoPair
 	}
-	return &messagelib.TickerValue{Ticker: assetWithName(asset), Value: float32(price), Change: float32(change) * 100.0}, nil
+	return getCandlesForPair(ctx, s.geminiClient, asset+currency, timeframe)
 }
 
-func GetCandleGraphForCryptoAsset(geminiClient *http.Client, cloudRunClient *http.Client, asset string) (string, error) {
-	candlesData, err := FetchCandles(geminiClient, asset)
+func (s *geminiSource) getRange(ctx context.Context, asset string, currency string) (*priceRange, error) {
+	ticker, err := fetchTicker(s.geminiClient, asset+currency)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	return &priceRange{open: parsePrice(ticker.Open), high: parsePrice(ticker.High), low: parsePrice(ticker.Low)}, nil
+}
 
-	url := os.Getenv("GET_CRYPTO_CANDLE_GRAPH_URL")
-	if url == "" {
-		return "", errors.New("no crypto candle graph url")
+func fetchTicker(geminiClient *http.Client, pair string) (*geminiTicker, error) {
+	url := geminiBaseURL + fmt.Sprintf(geminiTickerURIFormatString, pair)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for crypto ticker: %v", err)
 	}
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(candlesData))
-	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
 
-	res, err := cloudRunClient.Do(req)
+	req.Header.Set("User-Agent", brokerbotUserAgent)
+
+	res, err := geminiClient.Do(req)
 	if err != nil {
-		log.Printf("failed to execute request for crypto candles graph: %v", err)
-		return "", err
+		return nil, fmt.Errorf("failed to execute request for crypto ticker: %v", err)
 	}
+	defer res.Body.Close()
 
-	if res.Body != nil {
-		defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("crypto ticker request for %q returned %s", pair, res.Status)
 	}
 
-	if res.StatusCode != 200 {
-		log.Print("crypto candle image did not return")
-		return "", errors.New("crypto candle image did not return")
+	var ticker geminiTicker
+	if err := json.NewDecoder(res.Body).Decode(&ticker); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal crypto ticker response: %v", err)
+	}
+	return &ticker, nil
+}
+
+// parsePrice parses a Gemini price string, returning 0 if it isn't a number.
+func parsePrice(s string) float32 {
+	price, err := strconv.ParseFloat(s, 32)
+	if err != nil {
+		return 0
 	}
+	return float32(price)
+}
 
-	body, readErr := ioutil.ReadAll(res.Body)
-	if readErr != nil {
-		log.Printf("failed to read crypto candles graph response: %v", readErr)
-		return "", readErr
+func getCandlesForPair(ctx context.Context, geminiClient *http.Client, pair string, timeframe quotelib.Timeframe) ([]*quotelib.Candle, error) {
+	interval, ok := geminiCandleIntervals[timeframe]
+	if !ok {
+		return nil, fmt.Errorf("unsupported crypto timeframe: %q", timeframe.Name)
+	}
+	rows, err := fetchCandles(ctx, geminiClient, pair, interval)
+	if err != nil {
+		return nil, err
 	}
 
-	return string(body), nil
+	start := time.Now().Add(-timeframe.Duration)
+	candles := make([]*quotelib.Candle, 0, len(rows))
+	for i := len(rows) - 1; i >= 0; i-- {
+		row := rows[i]
+		if len(row) < 6 || time.UnixMilli(int64(row[0])).Before(start) {
+			continue
+		}
+		candles = append(candles, &quotelib.Candle{
+			Time:   time.UnixMilli(int64(row[0])),
+			Open:   float32(row[1]),
+			High:   float32(row[2]),
+			Low:    float32(row[3]),
+			Close:  float32(row[4]),
+			Volume: float32(row[5]),
+		})
+	}
+	return candles, nil
 }
 
 func getFeedForAsset(geminiClient *http.Client, asset string) (*PriceFeed, bool) {
 	FetchPriceFeeds(geminiClient)
-	for _, feed := range priceFeeds {
+	for _, feed := range GetLatestPriceFeed() {
 		if feed.Pair == asset {
 			return feed, true
 		}
@@ -121,14 +178,6 @@ func getFeedForAsset(geminiClient *http.Client, asset string) (*PriceFeed, bool)
 	return nil, false
 }
 
-func assetWithName(asset string) string {
-	name, ok := cryptoNames[asset]
-	if !ok {
-		return asset
-	}
-	return fmt.Sprintf("%s (%s)", asset, name)
-}
-
 func GetLatestPriceFeed() []*PriceFeed {
 	mu.Lock()
 	defer mu.Unlock()
@@ -141,95 +190,65 @@ func GetLatestPriceFeedUpdateTime() time.Time {
 	return lastUpdated
 }
 
+// FetchPriceFeeds refreshes the crypto price feeds if they are older than priceFeedAgeLimit.
+// If the refresh fails, the previous price feeds are kept.
 func FetchPriceFeeds(geminiClient *http.Client) {
-	mu.Lock()
-	defer mu.Unlock()
+	_, err := priceFeedCache.Get(geminiPriceFeedURI, func() (interface{}, error) {
+		log.Printf("Crypto price feeds are older than %v, fetching update.", *priceFeedAgeLimit)
+		newPriceFeeds, err := fetchPriceFeeds(geminiClient)
+		if err != nil {
+			return nil, err
+		}
 
-	if time.Since(lastUpdated) <= *priceFeedAgeLimit {
-		return
+		mu.Lock()
+		defer mu.Unlock()
+		priceFeeds = newPriceFeeds
+		lastUpdated = time.Now()
+		return newPriceFeeds, nil
+	})
+	if err != nil {
+		log.Println(err)
 	}
+}
 
-	log.Printf("Crypto price feeds are older than %v, fetching update.", *priceFeedAgeLimit)
-
+func fetchPriceFeeds(geminiClient *http.Client) ([]*PriceFeed, error) {
 	var newPriceFeeds []*PriceFeed
 
 	url := geminiBaseURL + geminiPriceFeedURI
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
-		log.Printf("failed to create request for crypto price feeds: %v", err)
-		return
+		return nil, fmt.Errorf("failed to create request for crypto price feeds: %v", err)
 	}
 
 	req.Header.Set("User-Agent", brokerbotUserAgent)
 
-	res, getErr := geminiClient.Do(req)
-	if getErr != nil {
-		log.Printf("failed to execute request for crypto price feeds: %v", err)
-		return
+	res, err := geminiClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request for crypto price feeds: %v", err)
 	}
 
 	if res.Body != nil {
 		defer res.Body.Close()
 	}
 
-	body, readErr := ioutil.ReadAll(res.Body)
-	if readErr != nil {
-		log.Printf("failed to read crypto price feed response: %v", err)
-		return
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read crypto price feed response: %v", err)
 	}
 
-	unmarshalErr := json.Unmarshal(body, &newPriceFeeds)
-	if unmarshalErr != nil {
-		log.Printf("failed to unmarshal crypto price feed response: %v", err)
-		return
+	if err := json.Unmarshal(body, &newPriceFeeds); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal crypto price feed response: %v", err)
 	}
 
-	priceFeeds = newPriceFeeds
-	lastUpdated = time.Now()
+	return newPriceFeeds, nil
 }
 
-func FetchCandles(geminiClient *http.Client, asset string) ([]byte, error) {
-	formattedAsset := asset + "USD"
-	mu.Lock()
-	defer mu.Unlock()
-
-	url := geminiBaseURL + fmt.Sprintf(geminiCandlesURIFormatString, formattedAsset)
-	req, err := http.NewRequest(http.MethodGet, url, nil)
-	if err != nil {
-		log.Printf("failed to create request for crypto candle: %v", err)
+// fetchCandles returns the Gemini candles for the pair at the given interval, e.g. "BTCUSD" and "15m".
+// Gemini candles are [time, open, high, low, close, volume] arrays, newest first.
+func fetchCandles(ctx context.Context, geminiClient *http.Client, pair string, interval string) ([][]float64, error) {
+	var rows [][]float64
+	if err := getJSON(ctx, geminiClient, geminiBaseURL+fmt.Sprintf(geminiCandlesURIFormatString, pair, interval), &rows); err != nil {
 		return nil, err
 	}
-
-	req.Header.Set("User-Agent", brokerbotUserAgent)
-
-	res, getErr := geminiClient.Do(req)
-	if getErr != nil {
-		log.Printf("failed to execute request for crypto candles: %v", err)
-		return nil, getErr
-	}
-
-	if res.Body != nil {
-		defer res.Body.Close()
-	}
-
-	body, readErr := ioutil.ReadAll(res.Body)
-	if readErr != nil {
-		log.Printf("failed to read crypto candles response: %v", err)
-		return nil, readErr
-	}
-
-	var tmp []interface{}
-	if unmarshalErr := json.Unmarshal(body, &tmp); unmarshalErr != nil {
-		return nil, unmarshalErr
-	}
-
-	if len(tmp) < 672 {
-		return body, nil
-	}
-
-	newReturn, marshallErr := json.Marshal(tmp[:672])
-	if marshallErr != nil {
-		return nil, marshallErr
-	}
-	return newReturn, nil
+	return rows, nil
 }
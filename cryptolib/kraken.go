@@ -0,0 +1,190 @@
+package cryptolib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/JoeParrinello/brokerbot/quotelib"
+)
+
+const (
+	krakenBaseURL    = "https://api.kraken.com"
+	krakenTickerURI  = "/0/public/Ticker"
+	krakenOHLCURI    = "/0/public/OHLC"
+	krakenUnknownErr = "EQuery:Unknown asset pair"
+)
+
+var (
+	// krakenIntervals is the Kraken candle interval, in minutes, used for each chart timeframe.
+	// Kraken returns at most 720 candles, which each interval stays under.
+	krakenIntervals = map[quotelib.Timeframe]int{
+		quotelib.OneDay:    5,
+		quotelib.FiveDays:  30,
+		quotelib.OneMonth:  240,
+		quotelib.SixMonths: 1440,
+		quotelib.OneYear:   1440,
+		quotelib.FiveYears: 10080,
+	}
+
+	// krakenAssets are Kraken's own codes for assets it doesn't list by their usual ticker.
+	krakenAssets = map[string]string{
+		"BTC":  "XBT",
+		"DOGE": "XDG",
+	}
+)
+
+// krakenResponse is the envelope of every Kraken response. Result is keyed by Kraken's name for the pair.
+type krakenResponse struct {
+	Error  []string                   `json:"error"`
+	Result map[string]json.RawMessage `json:"result"`
+}
+
+// krakenTicker is a pair in Kraken's ticker response. Prices are strings; the first
+// element of C is the last trade price, and O is the open at midnight UTC.
+type krakenTicker struct {
+	C []string `json:"c"`
+	H []string `json:"h"`
+	L []string `json:"l"`
+	O string   `json:"o"`
+}
+
+// krakenSource quotes crypto assets from the Kraken public API.
+type krakenSource struct {
+	client *http.Client
+}
+
+func newKrakenSource(client *http.Client) source {
+	return &krakenSource{client: client}
+}
+
+func (s *krakenSource) name() string {
+	return "Kraken"
+}
+
+// getQuote returns the change since midnight UTC, since Kraken doesn't give a 24 hour open.
+func (s *krakenSource) getQuote(ctx context.Context, asset string, currency string) (*quote, error) {
+	ticker, err := s.fetchTicker(ctx, asset, currency)
+	if err != nil {
+		return nil, err
+	}
+	if len(ticker.C) == 0 {
+		return nil, errNoPair
+	}
+	q := &quote{price: parsePrice(ticker.C[0])}
+	if open := parsePrice(ticker.O); open != 0 {
+		q.change = (q.price - open) / open * 100
+	}
+	return q, nil
+}
+
+// getRange uses the high and low of the last 24 hours, the second element of H and L.
+func (s *krakenSource) getRange(ctx context.Context, asset string, currency string) (*priceRange, error) {
+	ticker, err := s.fetchTicker(ctx, asset, currency)
+	if err != nil {
+		return nil, err
+	}
+	r := &priceRange{open: parsePrice(ticker.O)}
+	if len(ticker.H) > 1 && len(ticker.L) > 1 {
+		r.high = parsePrice(ticker.H[1])
+		r.low = parsePrice(ticker.L[1])
+	}
+	return r, nil
+}
+
+func (s *krakenSource) fetchTicker(ctx context.Context, asset string, currency string) (*krakenTicker, error) {
+	query := url.Values{}
+	query.Set("pair", krakenPair(asset, currency))
+	result, err := s.fetch(ctx, krakenTickerURI+"?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	var ticker krakenTicker
+	if err := json.Unmarshal(result, &ticker); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Kraken ticker: %v", err)
+	}
+	return &ticker, nil
+}
+
+func (s *krakenSource) getCandles(ctx context.Context, asset string, currency string, timeframe quotelib.Timeframe) ([]*quotelib.Candle, error) {
+	interval, ok := krakenIntervals[timeframe]
+	if !ok {
+		return nil, fmt.Errorf("unsupported crypto timeframe: %q", timeframe.Name)
+	}
+	start := time.Now().Add(-timeframe.Duration)
+	query := url.Values{}
+	query.Set("pair", krakenPair(asset, currency))
+	query.Set("interval", fmt.Sprint(interval))
+	query.Set("since", fmt.Sprint(start.Unix()))
+	result, err := s.fetch(ctx, krakenOHLCURI+"?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	// Kraken candles are [time, open, high, low, close, vwap, volume, count] arrays, oldest
+	// first, with prices and volume as strings.
+	var rows [][]interface{}
+	if err := json.Unmarshal(result, &rows); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Kraken candles: %v", err)
+	}
+	candles := make([]*quotelib.Candle, 0, len(rows))
+	for _, row := range rows {
+		if len(row) < 7 {
+			continue
+		}
+		t, ok := row[0].(float64)
+		if !ok {
+			continue
+		}
+		price := func(i int) float32 {
+			s, _ := row[i].(string)
+			return parsePrice(s)
+		}
+		candles = append(candles, &quotelib.Candle{
+			Time:   time.Unix(int64(t), 0),
+			Open:   price(1),
+			High:   price(2),
+			Low:    price(3),
+			Close:  price(4),
+			Volume: price(6),
+		})
+	}
+	return candles, nil
+}
+
+// fetch returns the result for the only pair in a Kraken response.
+func (s *krakenSource) fetch(ctx context.Context, uri string) (json.RawMessage, error) {
+	var res krakenResponse
+	if err := getJSON(ctx, s.client, krakenBaseURL+uri, &res); err != nil {
+		return nil, err
+	}
+	for _, e := range res.Error {
+		if e == krakenUnknownErr {
+			return nil, errNoPair
+		}
+	}
+	if len(res.Error) > 0 {
+		return nil, fmt.Errorf("Kraken request failed: %s", strings.Join(res.Error, ", "))
+	}
+	for pair, result := range res.Result {
+		// OHLC responses also have a "last" cursor next to the pair.
+		if pair != "last" {
+			return result, nil
+		}
+	}
+	return nil, errNoPair
+}
+
+func krakenPair(asset string, currency string) string {
+	if code, ok := krakenAssets[asset]; ok {
+		asset = code
+	}
+	if code, ok := krakenAssets[currency]; ok {
+		currency = code
+	}
+	return asset + currency
+}
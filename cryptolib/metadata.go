@@ -0,0 +1,165 @@
+package cryptolib
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/JoeParrinello/brokerbot/cachelib"
+)
+
+var (
+	assetsFile = flag.String("cryptoAssets", "", `Path of a JSON file overriding crypto asset metadata, keyed by ticker, e.g. {"LUNA": {"name": "Terra", "coingeckoId": "terra-luna-2"}}`)
+
+	assetListCache = cachelib.New("crypto assets", cachelib.NameTTL)
+	categoryCache  = cachelib.New("crypto categories", cachelib.NameTTL)
+
+	assetsMu sync.Mutex
+	// lastAssetList is the last asset list loaded, which is kept if a refresh fails.
+	lastAssetList *assetList
+	// assetOverrides are set from --cryptoAssets by LoadAssetOverrides.
+	assetOverrides map[string]*assetInfo
+)
+
+// assetInfo is metadata of a crypto asset. Zero values are unknown.
+type assetInfo struct {
+	Name string `json:"name"`
+	// Logo is the URL of the asset's logo.
+	Logo       string   `json:"logo"`
+	Categories []string `json:"categories"`
+	// CoinGeckoID identifies the asset to CoinGecko, e.g. "bitcoin".
+	CoinGeckoID string `json:"coingeckoId"`
+}
+
+// assetList is the metadata of every asset CoinGecko lists, by ticker and by CoinGecko ID.
+type assetList struct {
+	byTicker map[string]*assetInfo
+	byID     map[string]*assetInfo
+}
+
+// LoadAssetOverrides reads the --cryptoAssets file, if one is set. Its fields take precedence over
+// the metadata loaded from CoinGecko, and its tickers needn't be listed there, e.g. to name a coin
+// outside CoinGecko's largest, or to pick which coin an ambiguous ticker is.
+func LoadAssetOverrides() error {
+	if *assetsFile == "" {
+		return nil
+	}
+	b, err := ioutil.ReadFile(*assetsFile)
+	if err != nil {
+		return fmt.Errorf("failed to read crypto assets file: %v", err)
+	}
+	var overrides map[string]*assetInfo
+	if err := json.Unmarshal(b, &overrides); err != nil {
+		return fmt.Errorf("failed to unmarshal crypto assets file: %v", err)
+	}
+
+	assetsMu.Lock()
+	defer assetsMu.Unlock()
+	assetOverrides = make(map[string]*assetInfo, len(overrides))
+	for ticker, info := range overrides {
+		assetOverrides[strings.ToUpper(ticker)] = info
+	}
+	log.Printf("Loaded %d crypto asset overrides from %s", len(assetOverrides), *assetsFile)
+	return nil
+}
+
+// getAssetInfo returns the metadata of an asset, which is empty if it is unknown.
+func getAssetInfo(ctx context.Context, client *http.Client, asset string) *assetInfo {
+	list := getAssetList(ctx, client)
+	assetsMu.Lock()
+	override := assetOverrides[asset]
+	assetsMu.Unlock()
+
+	info := &assetInfo{}
+	listed := list.byTicker[asset]
+	if override != nil && override.CoinGeckoID != "" {
+		listed = list.byID[override.CoinGeckoID]
+	}
+	if listed != nil {
+		*info = *listed
+	}
+	if override == nil {
+		return info
+	}
+	if override.Name != "" {
+		info.Name = override.Name
+	}
+	if override.Logo != "" {
+		info.Logo = override.Logo
+	}
+	if len(override.Categories) > 0 {
+		info.Categories = override.Categories
+	}
+	if override.CoinGeckoID != "" {
+		info.CoinGeckoID = override.CoinGeckoID
+	}
+	return info
+}
+
+// getAssetCategories returns the categories of an asset, which CoinGecko only lists per coin.
+func getAssetCategories(ctx context.Context, client *http.Client, info *assetInfo) []string {
+	if len(info.Categories) > 0 || info.CoinGeckoID == "" {
+		return info.Categories
+	}
+	categories, err := categoryCache.Get(info.CoinGeckoID, func() (interface{}, error) {
+		return fetchCoinGeckoCategories(ctx, client, info.CoinGeckoID)
+	})
+	if err != nil {
+		log.Printf("Crypto category lookup failed, ignoring: %v", err)
+		return nil
+	}
+	return categories.([]string)
+}
+
+// getAssetList returns the asset list, refreshing it from CoinGecko when it is older than
+// --nameCacheTTL. If the refresh fails, the previous list is kept.
+func getAssetList(ctx context.Context, client *http.Client) *assetList {
+	list, err := assetListCache.Get(coinGeckoMarketsURI, func() (interface{}, error) {
+		markets, err := fetchCoinGeckoMarkets(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+		list := &assetList{
+			byTicker: make(map[string]*assetInfo, len(markets)),
+			byID:     make(map[string]*assetInfo, len(markets)),
+		}
+		for _, market := range markets {
+			info := &assetInfo{Name: market.Name, Logo: market.Image, CoinGeckoID: market.ID}
+			list.byID[market.ID] = info
+			// Markets are largest first, so a ticker shared by several coins is the largest of them.
+			ticker := strings.ToUpper(market.Symbol)
+			if _, ok := list.byTicker[ticker]; !ok {
+				list.byTicker[ticker] = info
+			}
+		}
+
+		assetsMu.Lock()
+		defer assetsMu.Unlock()
+		lastAssetList = list
+		return list, nil
+	})
+	if err != nil {
+		log.Printf("Crypto asset list refresh failed, keeping the previous list: %v", err)
+		assetsMu.Lock()
+		defer assetsMu.Unlock()
+		if lastAssetList == nil {
+			return &assetList{}
+		}
+		return lastAssetList
+	}
+	return list.(*assetList)
+}
+
+// assetWithName returns the asset with its name, e.g. "BTC (Bitcoin)", or just the asset if its name is unknown.
+func assetWithName(asset string, info *assetInfo) string {
+	if info.Name == "" {
+		return asset
+	}
+	return fmt.Sprintf("%s (%s)", asset, info.Name)
+}
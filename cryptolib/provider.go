@@ -0,0 +1,246 @@
+package cryptolib
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/JoeParrinello/brokerbot/cachelib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/quotelib"
+)
+
+const usd = "USD"
+
+var (
+	sourceNames = flag.String("cryptoSources", "gemini,coinbase,kraken,coingecko", "Comma separated crypto data sources in priority order. Later sources are used when earlier ones don't list a pair or fail.")
+
+	// newSources creates each source by the name it is given in --cryptoSources.
+	newSources = map[string]func(client *http.Client) source{
+		"gemini":    newGeminiSource,
+		"coinbase":  newCoinbaseSource,
+		"kraken":    newKrakenSource,
+		"coingecko": newCoinGeckoSource,
+	}
+
+	quoteCache = cachelib.New("crypto quotes", cachelib.PriceTTL)
+)
+
+// errNoPair is returned by a source that doesn't list an asset in a currency.
+var errNoPair = errors.New("pair not listed")
+
+// source is an exchange or aggregator that crypto assets can be quoted from.
+type source interface {
+	// name is shown in the footer of embeds quoted from the source, e.g. "Kraken".
+	name() string
+	// getQuote returns the latest price of the asset in the currency and its 24 hour change.
+	getQuote(ctx context.Context, asset string, currency string) (*quote, error)
+	// getCandles returns candles covering the timeframe for the asset in the currency, oldest first.
+	getCandles(ctx context.Context, asset string, currency string, timeframe quotelib.Timeframe) ([]*quotelib.Candle, error)
+}
+
+// rangeSource is a source that also knows an asset's 24 hour range, for detail embeds.
+type rangeSource interface {
+	getRange(ctx context.Context, asset string, currency string) (*priceRange, error)
+}
+
+type quote struct {
+	price float32
+	// change is the percent change over the last 24 hours.
+	change float32
+}
+
+type priceRange struct {
+	open float32
+	high float32
+	low  float32
+}
+
+// Provider is a quotelib.QuoteProvider for crypto assets. It queries its sources in priority
+// order, falling back to the next one when a source doesn't list a pair or fails.
+type Provider struct {
+	client  *http.Client
+	sources []source
+}
+
+// NewProvider returns a Provider using the sources named by --cryptoSources and the asset
+// metadata overrides in --cryptoAssets.
+func NewProvider(client *http.Client) (*Provider, error) {
+	if err := LoadAssetOverrides(); err != nil {
+		return nil, err
+	}
+	p := &Provider{client: client}
+	for _, name := range strings.Split(*sourceNames, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		newSource, ok := newSources[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown crypto source %q", name)
+		}
+		p.sources = append(p.sources, newSource(client))
+	}
+	if len(p.sources) == 0 {
+		return nil, errors.New("no crypto sources configured")
+	}
+	return p, nil
+}
+
+// GetQuote implements quotelib.QuoteProvider. Assets no source lists are returned without a price.
+func (p *Provider) GetQuote(ctx context.Context, asset string) (*messagelib.TickerValue, error) {
+	tickerValue, _, err := p.getQuote(ctx, asset, usd)
+	if errors.Is(err, quotelib.ErrNoMarket) {
+		return &messagelib.TickerValue{Ticker: assetWithName(asset, getAssetInfo(ctx, p.client, asset))}, nil
+	}
+	return tickerValue, err
+}
+
+// GetQuoteIn implements quotelib.CurrencyProvider using pairs quoted in other currencies, e.g. ETHBTC or BTCEUR.
+func (p *Provider) GetQuoteIn(ctx context.Context, asset string, currency string) (*messagelib.TickerValue, error) {
+	tickerValue, _, err := p.getQuote(ctx, asset, currency)
+	return tickerValue, err
+}
+
+// getQuote returns the quote of the asset in the currency from the first source that lists it,
+// and that source. If none do, it returns quotelib.ErrNoMarket, or the last error if any failed.
+func (p *Provider) getQuote(ctx context.Context, asset string, currency string) (*messagelib.TickerValue, source, error) {
+	var lastErr error = quotelib.ErrNoMarket
+	for _, s := range p.sources {
+		q, err := getCachedQuote(ctx, s, asset, currency)
+		if err != nil {
+			log.Printf("%s quote for %s%s failed, trying the next source: %v", s.name(), asset, currency, err)
+			lastErr = err
+			continue
+		}
+		if q == nil || q.price == 0 {
+			continue
+		}
+		info := getAssetInfo(ctx, p.client, asset)
+		tickerValue := &messagelib.TickerValue{
+			Ticker: assetWithName(asset, info),
+			Value:  q.price,
+			Change: q.change,
+			Logo:   info.Logo,
+			Source: s.name(),
+		}
+		if currency != usd {
+			tickerValue.Currency = currency
+		}
+		return tickerValue, s, nil
+	}
+	return nil, nil, lastErr
+}
+
+// getCachedQuote returns the quote of the asset from the source, or nil if the source doesn't list it.
+func getCachedQuote(ctx context.Context, s source, asset string, currency string) (*quote, error) {
+	q, err := quoteCache.Get(fmt.Sprintf("%s:%s%s", s.name(), asset, currency), func() (interface{}, error) {
+		q, err := s.getQuote(ctx, asset, currency)
+		if errors.Is(err, errNoPair) {
+			// Cache that the pair is missing, so the source isn't asked again on every quote.
+			return (*quote)(nil), nil
+		}
+		return q, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return q.(*quote), nil
+}
+
+// GetCandles implements quotelib.QuoteProvider.
+func (p *Provider) GetCandles(ctx context.Context, asset string, timeframe quotelib.Timeframe) ([]*quotelib.Candle, error) {
+	candles, err := p.GetCandlesIn(ctx, asset, usd, timeframe)
+	if errors.Is(err, quotelib.ErrNoMarket) {
+		return nil, nil
+	}
+	return candles, err
+}
+
+// GetCandlesIn implements quotelib.CurrencyProvider, using the first source with candles for the pair.
+func (p *Provider) GetCandlesIn(ctx context.Context, asset string, currency string, timeframe quotelib.Timeframe) ([]*quotelib.Candle, error) {
+	var lastErr error = quotelib.ErrNoMarket
+	for _, s := range p.sources {
+		candles, err := s.getCandles(ctx, asset, currency, timeframe)
+		if errors.Is(err, errNoPair) {
+			continue
+		}
+		if err != nil {
+			log.Printf("%s candles for %s%s failed, trying the next source: %v", s.name(), asset, currency, err)
+			lastErr = err
+			continue
+		}
+		if len(candles) > 0 {
+			return candles, nil
+		}
+	}
+	return nil, lastErr
+}
+
+// GetName implements quotelib.QuoteProvider.
+func (p *Provider) GetName(ctx context.Context, asset string) (string, error) {
+	return getAssetInfo(ctx, p.client, asset).Name, nil
+}
+
+// GetDetail implements quotelib.DetailProvider, with the asset's categories and the 24 hour range
+// from the source of the quote.
+func (p *Provider) GetDetail(ctx context.Context, asset string) (*messagelib.TickerDetail, error) {
+	info := getAssetInfo(ctx, p.client, asset)
+	tickerValue, s, err := p.getQuote(ctx, asset, usd)
+	if errors.Is(err, quotelib.ErrNoMarket) {
+		return &messagelib.TickerDetail{TickerValue: messagelib.TickerValue{Ticker: assetWithName(asset, info), Logo: info.Logo}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	detail := &messagelib.TickerDetail{
+		TickerValue: *tickerValue,
+		Categories:  getAssetCategories(ctx, p.client, info),
+	}
+
+	rs, ok := s.(rangeSource)
+	if !ok {
+		return detail, nil
+	}
+	r, err := rs.getRange(ctx, asset, usd)
+	if err != nil {
+		log.Printf("%s range lookup failed, ignoring: %v", s.name(), err)
+		return detail, nil
+	}
+	detail.Open = r.open
+	detail.High = r.high
+	detail.Low = r.low
+	return detail, nil
+}
+
+// getJSON decodes the response of a GET request to a source into v. Sources answer 404
+// for pairs they don't list, which is returned as errNoPair.
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request for %s: %v", url, err)
+	}
+	req.Header.Set("User-Agent", brokerbotUserAgent)
+
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request for %s: %v", url, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return errNoPair
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("request for %s returned %s", url, res.Status)
+	}
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to unmarshal response of %s: %v", url, err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package cryptolib
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/JoeParrinello/brokerbot/messagelib"
+)
+
+const (
+	// maxSuggestions is how many assets are suggested for a query.
+	maxSuggestions = 5
+	// maxTypos is the most characters a suggested asset's ticker may differ from the query by.
+	maxTypos = 1
+)
+
+// Suggest implements quotelib.Suggester with the assets whose ticker is close to the query or whose
+// name contains it, from the Gemini price feed and the asset list.
+func (p *Provider) Suggest(ctx context.Context, query string) ([]*messagelib.SearchResult, error) {
+	query = strings.ToUpper(strings.TrimPrefix(strings.TrimSpace(query), "$"))
+	if query == "" {
+		return nil, nil
+	}
+
+	assets := make(map[string]bool)
+	FetchPriceFeeds(p.client)
+	for _, feed := range GetLatestPriceFeed() {
+		if asset := strings.TrimSuffix(feed.Pair, usd); asset != feed.Pair {
+			assets[asset] = true
+		}
+	}
+	for asset := range getAssetList(ctx, p.client).byTicker {
+		assets[asset] = true
+	}
+
+	type match struct {
+		asset string
+		info  *assetInfo
+		score int
+	}
+	var matches []*match
+	for asset := range assets {
+		info := getAssetInfo(ctx, p.client, asset)
+		score, ok := matchScore(query, asset, info.Name)
+		if ok {
+			matches = append(matches, &match{asset: asset, info: info, score: score})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score < matches[j].score
+		}
+		return matches[i].asset < matches[j].asset
+	})
+
+	var results []*messagelib.SearchResult
+	for _, m := range matches {
+		if len(results) == maxSuggestions {
+			break
+		}
+		results = append(results, &messagelib.SearchResult{Ticker: "$" + m.asset, Name: m.info.Name, Type: "Crypto"})
+	}
+	return results, nil
+}
+
+// matchScore ranks how well an asset matches a query, lower first: its ticker, then tickers
+// starting with the query, then tickers a typo away, then names containing the query.
+func matchScore(query string, asset string, name string) (int, bool) {
+	switch {
+	case asset == query:
+		return 0, true
+	case strings.HasPrefix(asset, query):
+		return 1, true
+	}
+	if distance := editDistance(query, asset); distance <= maxTypos {
+		return 1 + distance, true
+	}
+	// Short queries match too many names to be useful, e.g. "ET".
+	if len(query) >= 3 && strings.Contains(strings.ToUpper(name), query) {
+		return 2 + maxTypos, true
+	}
+	return 0, false
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func minInt(values ...int) int {
+	ret := values[0]
+	for _, v := range values[1:] {
+		if v < ret {
+			ret = v
+		}
+	}
+	return ret
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/JoeParrinello/brokerbot/commandlib"
+	"github.com/JoeParrinello/brokerbot/currencylib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+)
+
+func init() {
+	commandlib.Register(&commandlib.Command{
+		Name:        "currency show",
+		Description: "Show the currency quotes are shown in on this server",
+		Usage:       "currency",
+		Implicit:    true,
+		Handler:     handleCurrencyShow,
+	})
+	commandlib.Register(&commandlib.Command{
+		Name:        "currency set",
+		Description: "Set the currency quotes are shown in on this server",
+		Args: []commandlib.Arg{
+			{Name: "currency", Description: "Currency code, e.g. EUR or BTC", Complete: completeCurrency},
+		},
+		Usage:   "currency set <currency>",
+		Handler: handleCurrencySet,
+	})
+}
+
+func handleCurrencyShow(ctx context.Context, r *commandlib.Request) error {
+	currency := currencylib.GetGuildCurrency(ctx, r.GuildID)
+	messagelib.ReplyMessage(r.Reply, fmt.Sprintf("Quotes are shown in %s. Add \"in <currency>\" to a quote to use another currency.", currency))
+	return nil
+}
+
+func handleCurrencySet(ctx context.Context, r *commandlib.Request) error {
+	if r.GuildID == "" {
+		return errors.New("currencies can only be set for servers")
+	}
+	if !canManageGuild(r) {
+		return errors.New("only members with Manage Server can set the server's currency")
+	}
+	currency, ok := currencylib.Parse(r.Args[0])
+	if !ok {
+		return fmt.Errorf("%q isn't a currency code", r.Args[0])
+	}
+	if _, err := currencylib.GetRate(ctx, currencylib.USD, currency); err != nil {
+		return err
+	}
+
+	if err := currencylib.SetGuildCurrency(ctx, r.GuildID, currency); err != nil {
+		return err
+	}
+	messagelib.ReplyMessage(r.Reply, fmt.Sprintf("Quotes on this server will be shown in %s.", currency))
+	return nil
+}
+
+// completeCurrency suggests common currencies starting with partial.
+func completeCurrency(ctx context.Context, r *commandlib.Request, partial string) []string {
+	partial = strings.ToUpper(strings.TrimSpace(strings.TrimPrefix(strings.ToLower(partial), "in ")))
+	var currencies []string
+	for _, currency := range currencylib.Common {
+		if strings.HasPrefix(currency, partial) {
+			currencies = append(currencies, currency)
+		}
+	}
+	return currencies
+}
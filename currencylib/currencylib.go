@@ -0,0 +1,239 @@
+package currencylib
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Finnhub-Stock-API/finnhub-go"
+	"github.com/JoeParrinello/brokerbot/cachelib"
+	"github.com/JoeParrinello/brokerbot/firestorelib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/quotelib"
+	"github.com/JoeParrinello/brokerbot/ratelimitlib"
+	"github.com/antihax/optional"
+)
+
+// USD is the currency providers quote in unless asked for another.
+const USD = "USD"
+
+var (
+	ratesTTL   = flag.Duration("forexRatesTTL", 10*time.Minute, "How long forex rates used for currency conversion are cached")
+	ratesCache = cachelib.New("forex rates", ratesTTL)
+	// guildCurrencyCache saves a settings read on every quote. SetGuildCurrency keeps it up to date.
+	guildCurrencyCache = cachelib.New("guild currencies", cachelib.NameTTL)
+
+	finnhubClient *finnhub.DefaultApiService
+
+	// pegged currencies trade at a fixed rate to USD and aren't in the forex rates.
+	pegged = map[string]float64{
+		USD:    1,
+		"GUSD": 1,
+	}
+
+	// Common lists the currencies suggested when completing commands. Any currency with a forex rate or a crypto USD market works.
+	Common = []string{"USD", "EUR", "GBP", "JPY", "CAD", "AUD", "CHF", "CNY", "HKD", "INR", "BTC", "ETH", "GUSD"}
+)
+
+// Init sets the Finnhub client forex rates are fetched with.
+func Init(f *finnhub.DefaultApiService) {
+	finnhubClient = f
+}
+
+// Parse canonicalizes a currency code from a message, e.g. "eur" to "EUR".
+// It returns false if s can't be a currency code, without checking that a rate exists.
+func Parse(s string) (string, bool) {
+	s = strings.ToUpper(s)
+	if len(s) < 3 || len(s) > 5 {
+		return "", false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return "", false
+		}
+	}
+	return s, true
+}
+
+// GetRate returns how many units of the to currency one unit of the from currency is worth.
+func GetRate(ctx context.Context, from string, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	fromRate, err := usdRate(ctx, from)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := usdRate(ctx, to)
+	if err != nil {
+		return 0, err
+	}
+	return toRate / fromRate, nil
+}
+
+// usdRate returns how many units of the currency one USD is worth.
+func usdRate(ctx context.Context, currency string) (float64, error) {
+	if rate, ok := pegged[currency]; ok {
+		return rate, nil
+	}
+	rates, err := getForexRates(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if rate, ok := rates[currency]; ok && rate > 0 {
+		return rate, nil
+	}
+	// Crypto currencies aren't in the forex rates, so price them from their USD market instead.
+	if quote, err := quotelib.GetQuote(ctx, "$"+currency); err == nil && quote.Value > 0 {
+		return 1 / float64(quote.Value), nil
+	}
+	return 0, fmt.Errorf("unsupported currency %q", currency)
+}
+
+// getForexRates returns how many units of each fiat currency one USD is worth.
+func getForexRates(ctx context.Context) (map[string]float64, error) {
+	rates, err := ratesCache.Get(USD, func() (interface{}, error) {
+		if finnhubClient == nil {
+			return nil, errors.New("forex rates not initialized")
+		}
+		var forexRates finnhub.Forexrates
+		err := ratelimitlib.Finnhub.Do(ctx, func() (res *http.Response, err error) {
+			forexRates, res, err = finnhubClient.ForexRates(ctx, &finnhub.ForexRatesOpts{Base: optional.NewString(USD)})
+			return res, err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get forex rates: %v", err)
+		}
+		rates := make(map[string]float64, len(forexRates.Quote))
+		for currency, rate := range forexRates.Quote {
+			if rate, ok := rate.(float64); ok {
+				rates[strings.ToUpper(currency)] = rate
+			}
+		}
+		return rates, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rates.(map[string]float64), nil
+}
+
+// GetQuote returns the latest quote for a ticker in the currency. The provider's market
+// in the currency is used if it has one, and otherwise its USD quote is converted.
+func GetQuote(ctx context.Context, provider quotelib.QuoteProvider, ticker string, currency string) (*messagelib.TickerValue, error) {
+	if currency == USD {
+		return provider.GetQuote(ctx, ticker)
+	}
+	if p, ok := provider.(quotelib.CurrencyProvider); ok {
+		tickerValue, err := p.GetQuoteIn(ctx, ticker, currency)
+		if !errors.Is(err, quotelib.ErrNoMarket) {
+			return tickerValue, err
+		}
+	}
+	tickerValue, err := provider.GetQuote(ctx, ticker)
+	if err != nil {
+		return nil, err
+	}
+	if err := ConvertQuote(ctx, tickerValue, currency); err != nil {
+		return nil, err
+	}
+	return tickerValue, nil
+}
+
+// ConvertQuote converts the prices of a quote to the currency at the current rate.
+// Percent changes are left as they were in the quote's original currency.
+func ConvertQuote(ctx context.Context, tickerValue *messagelib.TickerValue, currency string) error {
+	from := tickerValue.Currency
+	if from == "" {
+		from = USD
+	}
+	rate, err := GetRate(ctx, from, currency)
+	if err != nil {
+		return err
+	}
+	tickerValue.Value = float32(float64(tickerValue.Value) * rate)
+	if tickerValue.ExtendedHours != nil {
+		tickerValue.ExtendedHours.Value = float32(float64(tickerValue.ExtendedHours.Value) * rate)
+	}
+	tickerValue.Currency = currency
+	return nil
+}
+
+// GetCandles returns candles covering the timeframe for a ticker in the currency. The provider's
+// market in the currency is used if it has one, and otherwise its USD candles are converted at
+// the current rate.
+func GetCandles(ctx context.Context, provider quotelib.QuoteProvider, ticker string, currency string, timeframe quotelib.Timeframe) ([]*quotelib.Candle, error) {
+	if currency == USD {
+		return provider.GetCandles(ctx, ticker, timeframe)
+	}
+	if p, ok := provider.(quotelib.CurrencyProvider); ok {
+		candles, err := p.GetCandlesIn(ctx, ticker, currency, timeframe)
+		if !errors.Is(err, quotelib.ErrNoMarket) {
+			return candles, err
+		}
+	}
+	candles, err := provider.GetCandles(ctx, ticker, timeframe)
+	if err != nil {
+		return nil, err
+	}
+	rate, err := GetRate(ctx, USD, currency)
+	if err != nil {
+		return nil, err
+	}
+	converted := make([]*quotelib.Candle, len(candles))
+	for i, candle := range candles {
+		converted[i] = &quotelib.Candle{
+			Time:   candle.Time,
+			Open:   float32(float64(candle.Open) * rate),
+			High:   float32(float64(candle.High) * rate),
+			Low:    float32(float64(candle.Low) * rate),
+			Close:  float32(float64(candle.Close) * rate),
+			Volume: candle.Volume,
+		}
+	}
+	return converted, nil
+}
+
+// GetGuildCurrency returns the currency a guild shows quotes in, or USD if it hasn't set one.
+func GetGuildCurrency(ctx context.Context, guildID string) string {
+	if guildID == "" {
+		return USD
+	}
+	currency, err := guildCurrencyCache.Get(guildID, func() (interface{}, error) {
+		settings, err := firestorelib.GetGuildSettings(ctx, guildID)
+		if err != nil {
+			return nil, err
+		}
+		if settings.Currency == "" {
+			return USD, nil
+		}
+		return settings.Currency, nil
+	})
+	if err != nil {
+		log.Printf("failed to get currency of guild %q, using %s: %v", guildID, USD, err)
+		return USD
+	}
+	return currency.(string)
+}
+
+// SetGuildCurrency sets the currency a guild shows quotes in.
+func SetGuildCurrency(ctx context.Context, guildID string, currency string) error {
+	settings, err := firestorelib.GetGuildSettings(ctx, guildID)
+	if err != nil {
+		return err
+	}
+	settings.Currency = currency
+	if currency == USD {
+		settings.Currency = ""
+	}
+	if err := firestorelib.SetGuildSettings(ctx, settings); err != nil {
+		return err
+	}
+	guildCurrencyCache.Delete(guildID)
+	return nil
+}
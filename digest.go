@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JoeParrinello/brokerbot/commandlib"
+	"github.com/JoeParrinello/brokerbot/digestlib"
+	"github.com/JoeParrinello/brokerbot/firestorelib"
+	"github.com/JoeParrinello/brokerbot/marketlib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+)
+
+const maxDigestsPerChannel = 10
+
+func init() {
+	commandlib.Register(&commandlib.Command{
+		Name:        "digest subscribe",
+		Description: "Post quotes for tickers in this channel at a time each trading day",
+		Args: []commandlib.Arg{
+			{Name: "time", Description: "Time of day, optionally with a time zone and \"daily\", e.g. 09:35 America/New_York"},
+			{Name: "tickers", Description: "Tickers to quote, e.g. ?TECH AAPL $BTC", Variadic: true, Complete: completeTicker},
+		},
+		Usage:   "digest subscribe <ticker> <ticker> ... at <H:MM> [time zone] [daily]",
+		Handler: handleDigestSubscribe,
+	})
+	commandlib.Register(&commandlib.Command{
+		Name:        "digest list",
+		Description: "List the digests posted in this channel",
+		Handler:     handleDigestList,
+	})
+	commandlib.Register(&commandlib.Command{
+		Name:        "digest unsubscribe",
+		Description: "Stop posting a digest in this channel",
+		Args: []commandlib.Arg{
+			{Name: "id", Description: "Digest ID from digest list"},
+		},
+		Handler: handleDigestUnsubscribe,
+	})
+}
+
+func handleDigestSubscribe(ctx context.Context, r *commandlib.Request) error {
+	if r.GuildID != "" && !canManageGuild(r) {
+		return errors.New("only members with Manage Server can subscribe channels to digests")
+	}
+
+	digest := &firestorelib.Digest{
+		GuildID:   r.GuildID,
+		ChannelID: r.ChannelID,
+		CreatorID: r.UserID,
+		Timezone:  marketlib.Location().String(),
+		Created:   time.Now(),
+	}
+	tickers, ok := digestlib.ParseArgs(digest, strings.Fields(strings.Join(r.Args, " ")))
+	if !ok {
+		return commandlib.ErrUsage
+	}
+	digest.Tickers = messagelib.CanonicalizeMessage(messagelib.RemoveMentions(tickers))
+	if len(digest.Tickers) == 0 {
+		return commandlib.ErrUsage
+	}
+	if _, err := digestlib.GetSchedule(digest); err != nil {
+		return err
+	}
+
+	existing, err := firestorelib.GetDigests(ctx, r.ChannelID)
+	if err != nil {
+		return err
+	}
+	if len(existing) >= maxDigestsPerChannel {
+		return fmt.Errorf("channels can have at most %d digests", maxDigestsPerChannel)
+	}
+
+	id, err := firestorelib.CreateDigest(ctx, digest)
+	if err != nil {
+		return err
+	}
+	messagelib.ReplyMessage(r.Reply, fmt.Sprintf("Created digest %s: %s", id, digestlib.Describe(digest)))
+	return nil
+}
+
+func handleDigestList(ctx context.Context, r *commandlib.Request) error {
+	digests, err := firestorelib.GetDigests(ctx, r.ChannelID)
+	if err != nil {
+		return err
+	}
+	if len(digests) == 0 {
+		messagelib.ReplyMessage(r.Reply, "This channel has no digests.")
+		return nil
+	}
+	var b strings.Builder
+	for _, digest := range digests {
+		b.WriteString(fmt.Sprintf("%s: %s\n", digest.ID, digestlib.Describe(digest)))
+	}
+	messagelib.ReplyMessage(r.Reply, b.String())
+	return nil
+}
+
+func handleDigestUnsubscribe(ctx context.Context, r *commandlib.Request) error {
+	if r.GuildID != "" && !canManageGuild(r) {
+		return errors.New("only members with Manage Server can unsubscribe channels from digests")
+	}
+	if err := firestorelib.DeleteDigest(ctx, r.Args[0], r.ChannelID); err != nil {
+		return fmt.Errorf("failed to delete digest: %v", err)
+	}
+	messagelib.ReplyMessage(r.Reply, fmt.Sprintf("Deleted digest %q", r.Args[0]))
+	return nil
+}
@@ -0,0 +1,170 @@
+package digestlib
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/JoeParrinello/brokerbot/currencylib"
+	"github.com/JoeParrinello/brokerbot/firestorelib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/quotelib"
+	"github.com/JoeParrinello/brokerbot/schedulerlib"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	checkInterval = time.Minute
+	dailyOption   = "daily"
+)
+
+var (
+	maxLateness = flag.Duration("digestMaxLateness", 30*time.Minute, "How late a digest may be sent, e.g. after a restart, before it is skipped until its next scheduled time")
+
+	// clockPattern matches a 24 hour time of day such as "09:35" or "9:35".
+	clockPattern = regexp.MustCompile(`^([01]?[0-9]|2[0-3]):[0-5][0-9]$`)
+)
+
+// Start sends digests to their channels as they come due until shutdown.
+func Start(ctx context.Context, s *discordgo.Session) {
+	schedulerlib.Every(ctx, "digests", checkInterval, func(ctx context.Context) {
+		checkDigests(ctx, s)
+	})
+}
+
+// ParseArgs sets a digest's schedule from the fields of a subscribe command and returns its tickers.
+// The schedule is a time of day followed by an optional time zone and "daily". It either leads, as
+// in the slash command, or ends the fields after "at", so tickers such as AT aren't mistaken for it.
+// It returns false if there's no schedule or anything follows a trailing one.
+func ParseArgs(digest *firestorelib.Digest, fields []string) ([]string, bool) {
+	var tickers, schedule []string
+	leading := false
+	switch {
+	case len(fields) > 0 && clockPattern.MatchString(fields[0]):
+		schedule, leading = fields, true
+	case len(fields) > 1 && strings.EqualFold(fields[0], "at") && clockPattern.MatchString(fields[1]):
+		schedule, leading = fields[1:], true
+	default:
+		for i := len(fields) - 2; i >= 0; i-- {
+			if strings.EqualFold(fields[i], "at") && clockPattern.MatchString(fields[i+1]) {
+				tickers, schedule = fields[:i], fields[i+1:]
+				break
+			}
+		}
+	}
+	if len(schedule) == 0 {
+		return nil, false
+	}
+
+	t, err := time.Parse("15:04", schedule[0])
+	if err != nil {
+		return nil, false
+	}
+	digest.Time = t.Format("15:04")
+	rest := schedule[1:]
+	if len(rest) > 0 && isTimezone(rest[0]) {
+		digest.Timezone = rest[0]
+		rest = rest[1:]
+	}
+	if len(rest) > 0 && strings.EqualFold(rest[0], dailyOption) {
+		digest.Daily = true
+		rest = rest[1:]
+	}
+	if leading {
+		return rest, true
+	}
+	return tickers, len(rest) == 0
+}
+
+// isTimezone reports whether s names an IANA time zone such as "America/New_York".
+// Abbreviations like "EST" are rejected since they could be tickers.
+func isTimezone(s string) bool {
+	if !strings.Contains(s, "/") && s != "UTC" {
+		return false
+	}
+	_, err := time.LoadLocation(s)
+	return err == nil
+}
+
+// GetSchedule returns when a digest is sent.
+func GetSchedule(digest *firestorelib.Digest) (*schedulerlib.Schedule, error) {
+	return schedulerlib.ParseSchedule(digest.Time, digest.Timezone, !digest.Daily)
+}
+
+// Describe returns a readable summary of a digest, e.g. "?TECH AAPL at 09:35 America/New_York on trading days".
+func Describe(digest *firestorelib.Digest) string {
+	days := "on trading days"
+	if digest.Daily {
+		days = "daily"
+	}
+	return fmt.Sprintf("%s at %s %s %s", strings.Join(digest.Tickers, " "), digest.Time, digest.Timezone, days)
+}
+
+func checkDigests(ctx context.Context, s *discordgo.Session) {
+	digests, err := firestorelib.GetDigests(ctx, "")
+	if err != nil {
+		log.Printf("failed to get digests: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, digest := range digests {
+		schedule, err := GetSchedule(digest)
+		if err != nil {
+			log.Printf("failed to get schedule of digest %q: %v", digest.ID, err)
+			continue
+		}
+		due := schedule.LastDue(now)
+		if due.IsZero() || !digest.LastSent.Before(due) || now.Sub(due) > *maxLateness {
+			continue
+		}
+		// Failed digests are retried each check until they're too late.
+		if err := Send(ctx, s, digest); err != nil {
+			log.Printf("failed to send digest %q: %v", digest.ID, err)
+			continue
+		}
+		digest.LastSent = now
+		if err := firestorelib.UpdateDigest(ctx, digest); err != nil {
+			log.Printf("failed to update digest %q: %v", digest.ID, err)
+		}
+	}
+}
+
+// Send posts quotes for a digest's tickers to its channel, in its guild's currency.
+func Send(ctx context.Context, s *discordgo.Session, digest *firestorelib.Digest) error {
+	tickers, err := messagelib.ExpandAliases(ctx, digest.GuildID, digest.Tickers)
+	if err != nil {
+		return fmt.Errorf("failed to expand aliases: %v", err)
+	}
+	quotes, failedTickers := quotelib.GetQuotes(ctx, messagelib.DedupeSlice(tickers))
+	if len(quotes) == 0 {
+		return fmt.Errorf("failed to get quotes for: %s", strings.Join(failedTickers, ", "))
+	}
+	if currency := currencylib.GetGuildCurrency(ctx, digest.GuildID); currency != currencylib.USD {
+		for _, quote := range quotes {
+			// Quotes that set a currency, like forex pairs, are priced in the market they trade in.
+			if quote.Currency != "" {
+				continue
+			}
+			if err := currencylib.ConvertQuote(ctx, quote, currency); err != nil {
+				// A rate that fails for one quote fails for all of them, so send prices unconverted.
+				log.Printf("failed to convert digest %q to %s, sending %s: %v", digest.ID, currency, currencylib.USD, err)
+				break
+			}
+		}
+	}
+
+	embed := messagelib.CreateMultiMessageEmbed(quotes)
+	embed.Title = fmt.Sprintf("Digest: %s", strings.Join(digest.Tickers, " "))
+	if len(failedTickers) > 0 {
+		embed.Description = fmt.Sprintf("Couldn't get quotes for: %s (See logs)", strings.Join(failedTickers, ", "))
+	}
+	if messagelib.SendMessageEmbed(s, digest.ChannelID, embed) == nil {
+		return fmt.Errorf("failed to post digest to channel %q", digest.ChannelID)
+	}
+	return nil
+}
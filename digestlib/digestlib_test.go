@@ -0,0 +1,53 @@
+package digestlib
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JoeParrinello/brokerbot/firestorelib"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		args        string
+		wantTickers []string
+		wantTime    string
+		wantZone    string
+		wantDaily   bool
+		wantOK      bool
+	}{
+		{args: "AAPL MSFT at 09:35", wantTickers: []string{"AAPL", "MSFT"}, wantTime: "09:35", wantOK: true},
+		{args: "AAPL at 9:35", wantTickers: []string{"AAPL"}, wantTime: "09:35", wantOK: true},
+		{args: "?TECH at 16:05 America/Chicago daily", wantTickers: []string{"?TECH"}, wantTime: "16:05", wantZone: "America/Chicago", wantDaily: true, wantOK: true},
+		{args: "AAPL at 9:35 DAILY", wantTickers: []string{"AAPL"}, wantTime: "09:35", wantDaily: true, wantOK: true},
+		{args: "AT at 9:35", wantTickers: []string{"AT"}, wantTime: "09:35", wantOK: true},
+		{args: "AT AAPL at 9:35", wantTickers: []string{"AT", "AAPL"}, wantTime: "09:35", wantOK: true},
+		{args: "9:35 AAPL AT", wantTickers: []string{"AAPL", "AT"}, wantTime: "09:35", wantOK: true},
+		{args: "09:35 America/New_York daily AAPL $BTC", wantTickers: []string{"AAPL", "$BTC"}, wantTime: "09:35", wantZone: "America/New_York", wantDaily: true, wantOK: true},
+		{args: "at 9:35 AAPL", wantTickers: []string{"AAPL"}, wantTime: "09:35", wantOK: true},
+		{args: "AAPL at 9:35 MSFT", wantOK: false},
+		{args: "AAPL 9:35", wantOK: false},
+		{args: "AAPL at 24:00", wantOK: false},
+		{args: "AAPL at 9:5", wantOK: false},
+		{args: "AAPL", wantOK: false},
+		{args: "", wantOK: false},
+	}
+	for _, tt := range tests {
+		digest := &firestorelib.Digest{}
+		tickers, ok := ParseArgs(digest, strings.Fields(tt.args))
+		if ok != tt.wantOK {
+			t.Errorf("ParseArgs(%q) ok = %v, want %v", tt.args, ok, tt.wantOK)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if !reflect.DeepEqual(tickers, tt.wantTickers) {
+			t.Errorf("ParseArgs(%q) = %q, want %q", tt.args, tickers, tt.wantTickers)
+		}
+		if digest.Time != tt.wantTime || digest.Timezone != tt.wantZone || digest.Daily != tt.wantDaily {
+			t.Errorf("ParseArgs(%q) schedule = %q %q daily %v, want %q %q daily %v", tt.args, digest.Time, digest.Timezone, digest.Daily, tt.wantTime, tt.wantZone, tt.wantDaily)
+		}
+	}
+}
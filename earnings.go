@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/JoeParrinello/brokerbot/commandlib"
+	"github.com/JoeParrinello/brokerbot/firestorelib"
+	"github.com/JoeParrinello/brokerbot/marketlib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/quotelib"
+	"github.com/JoeParrinello/brokerbot/stocklib"
+)
+
+const (
+	// Earnings are reported quarterly, so this always covers the next release.
+	earningsLookahead = 120 * 24 * time.Hour
+	earningsSurprises = 4
+	// The weekly calendar has hundreds of releases, so only list the largest by revenue estimate.
+	notableEarnings = 20
+)
+
+func init() {
+	commandlib.Register(&commandlib.Command{
+		Name:        "earnings next",
+		Description: "Show a company's next earnings date, EPS estimate and recent surprises",
+		Args: []commandlib.Arg{
+			{Name: "ticker", Description: "Company ticker, e.g. NVDA", Complete: completeTicker},
+		},
+		Usage:    "earnings <ticker>",
+		Implicit: true,
+		Handler:  handleEarningsNext,
+	})
+	commandlib.Register(&commandlib.Command{
+		Name:        "earnings week",
+		Description: "List notable earnings reports this week",
+		Handler:     handleEarningsWeek,
+	})
+	commandlib.Register(&commandlib.Command{
+		Name:        "earnings subscribe",
+		Description: "Post a morning digest of earnings for this server's aliased stocks in this channel",
+		Handler:     handleEarningsSubscribe,
+	})
+	commandlib.Register(&commandlib.Command{
+		Name:        "earnings unsubscribe",
+		Description: "Stop posting earnings digests in this channel",
+		Handler:     handleEarningsUnsubscribe,
+	})
+}
+
+func handleEarningsNext(ctx context.Context, r *commandlib.Request) error {
+	tickers := messagelib.CanonicalizeMessage(messagelib.RemoveMentions(r.Args[:1]))
+	if len(tickers) == 0 {
+		return commandlib.ErrUsage
+	}
+	ticker, class := quotelib.ParseTicker(tickers[0])
+	if class != quotelib.Stock {
+		return fmt.Errorf("earnings are only available for stocks")
+	}
+
+	now := time.Now()
+	events, err := stocklib.GetEarningsCalendar(ctx, finnhubClient, now, now.Add(earningsLookahead), ticker)
+	if err != nil {
+		return err
+	}
+	var next *messagelib.EarningsEvent
+	if len(events) > 0 {
+		next = events[0]
+	}
+
+	surprises, err := stocklib.GetEarningsSurprises(ctx, finnhubClient, ticker, earningsSurprises)
+	if err != nil {
+		// The next date is still useful without history.
+		log.Printf("Earnings surprise lookup failed, ignoring: %v", err)
+	}
+	messagelib.ReplyMessageEmbed(r.Reply, messagelib.CreateEarningsEmbed(ticker, next, surprises))
+	return nil
+}
+
+func handleEarningsWeek(ctx context.Context, r *commandlib.Request) error {
+	// On weekends, show the coming week.
+	monday := marketlib.GetDay(time.Now()).Date
+	switch monday.Weekday() {
+	case time.Saturday:
+		monday = monday.AddDate(0, 0, 2)
+	case time.Sunday:
+		monday = monday.AddDate(0, 0, 1)
+	default:
+		monday = monday.AddDate(0, 0, -int(monday.Weekday()-time.Monday))
+	}
+	friday := monday.AddDate(0, 0, 4)
+
+	events, err := stocklib.GetEarningsCalendar(ctx, finnhubClient, monday, friday, "")
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].RevenueEstimate > events[j].RevenueEstimate
+	})
+	if len(events) > notableEarnings {
+		events = events[:notableEarnings]
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Date.Before(events[j].Date)
+	})
+
+	title := fmt.Sprintf("Earnings for the week of %s", monday.Format("Jan 2"))
+	messagelib.ReplyMessageEmbed(r.Reply, messagelib.CreateEarningsCalendarEmbed(title, events))
+	return nil
+}
+
+func handleEarningsSubscribe(ctx context.Context, r *commandlib.Request) error {
+	if r.GuildID == "" {
+		return errors.New("earnings digests can only be sent to server channels")
+	}
+	if !canManageGuild(r) {
+		return errors.New("only members with Manage Server can subscribe channels to earnings digests")
+	}
+	if err := firestorelib.SetEarningsDigest(ctx, &firestorelib.EarningsDigest{
+		GuildID:   r.GuildID,
+		ChannelID: r.ChannelID,
+		CreatorID: r.UserID,
+		Created:   time.Now(),
+	}); err != nil {
+		return err
+	}
+	messagelib.ReplyMessage(r.Reply, "This channel will get a digest of earnings for stocks in this server's aliases each trading morning.")
+	return nil
+}
+
+func handleEarningsUnsubscribe(ctx context.Context, r *commandlib.Request) error {
+	if !canManageGuild(r) {
+		return errors.New("only members with Manage Server can unsubscribe channels from earnings digests")
+	}
+	deleted, err := firestorelib.DeleteEarningsDigest(ctx, r.ChannelID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		messagelib.ReplyMessage(r.Reply, "This channel isn't subscribed to earnings digests.")
+		return nil
+	}
+	messagelib.ReplyMessage(r.Reply, "This channel will no longer get earnings digests.")
+	return nil
+}
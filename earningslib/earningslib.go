@@ -0,0 +1,111 @@
+package earningslib
+
+import (
+	"context"
+	"flag"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Finnhub-Stock-API/finnhub-go"
+	"github.com/JoeParrinello/brokerbot/firestorelib"
+	"github.com/JoeParrinello/brokerbot/marketlib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/quotelib"
+	"github.com/JoeParrinello/brokerbot/schedulerlib"
+	"github.com/JoeParrinello/brokerbot/stocklib"
+	"github.com/bwmarrin/discordgo"
+)
+
+const checkInterval = time.Minute
+
+var digestTime = flag.String("earningsDigestTime", "08:00", "Time of day, in New York, to send earnings digests on trading days")
+
+// Start sends earnings digests to subscribed channels each trading morning until shutdown.
+func Start(ctx context.Context, s *discordgo.Session, f *finnhub.DefaultApiService) {
+	schedule, err := schedulerlib.ParseSchedule(*digestTime, marketlib.Location().String(), true)
+	if err != nil {
+		log.Fatalf("failed to parse earnings digest time: %v", err)
+	}
+	schedulerlib.Every(ctx, "earnings digests", checkInterval, func(ctx context.Context) {
+		checkDigests(ctx, s, f, schedule)
+	})
+}
+
+func checkDigests(ctx context.Context, s *discordgo.Session, f *finnhub.DefaultApiService, schedule *schedulerlib.Schedule) {
+	now := time.Now()
+	due := schedule.LastDue(now)
+	// Only send today's digest, not one missed on an earlier day.
+	if due.IsZero() || !marketlib.GetDay(due).Date.Equal(marketlib.GetDay(now).Date) {
+		return
+	}
+
+	digests, err := firestorelib.GetEarningsDigests(ctx)
+	if err != nil {
+		log.Printf("failed to get earnings digests: %v", err)
+		return
+	}
+	var pending []*firestorelib.EarningsDigest
+	for _, digest := range digests {
+		if digest.LastSent.Before(due) {
+			pending = append(pending, digest)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	// Every guild shares today's calendar, so only fetch it once.
+	events, err := stocklib.GetEarningsCalendar(ctx, f, now, now, "")
+	if err != nil {
+		log.Printf("failed to get earnings for digests: %v", err)
+		return
+	}
+	for _, digest := range pending {
+		if err := sendDigest(ctx, s, digest, events); err != nil {
+			log.Printf("failed to send earnings digest to channel %q: %v", digest.ChannelID, err)
+			continue
+		}
+		digest.LastSent = now
+		if err := firestorelib.SetEarningsDigest(ctx, digest); err != nil {
+			log.Printf("failed to update earnings digest for channel %q: %v", digest.ChannelID, err)
+		}
+	}
+}
+
+// sendDigest posts the events for stocks in the digest guild's aliases. Nothing is posted if none report.
+func sendDigest(ctx context.Context, s *discordgo.Session, digest *firestorelib.EarningsDigest, events []*messagelib.EarningsEvent) error {
+	tickers, err := GetAliasedStocks(ctx, digest.GuildID)
+	if err != nil {
+		return err
+	}
+	var reporting []*messagelib.EarningsEvent
+	for _, event := range events {
+		if tickers[event.Ticker] {
+			reporting = append(reporting, event)
+		}
+	}
+	if len(reporting) == 0 {
+		return nil
+	}
+	messagelib.SendMessageEmbed(s, digest.ChannelID, messagelib.CreateEarningsCalendarEmbed("Earnings Today", reporting))
+	return nil
+}
+
+// GetAliasedStocks returns the set of stock tickers in the aliases visible to a guild.
+func GetAliasedStocks(ctx context.Context, guildID string) (map[string]bool, error) {
+	aliases, err := firestorelib.GetAliases(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	tickers := make(map[string]bool)
+	for _, assets := range aliases {
+		for _, asset := range assets {
+			asset = strings.ToUpper(asset)
+			if ticker, class := quotelib.ParseTicker(asset); class == quotelib.Stock && !strings.HasPrefix(ticker, "?") {
+				tickers[ticker] = true
+			}
+		}
+	}
+	return tickers, nil
+}
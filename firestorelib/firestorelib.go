@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"cloud.google.com/go/firestore"
 	"github.com/JoeParrinello/brokerbot/shutdownlib"
@@ -14,11 +15,18 @@ import (
 )
 
 var (
-	cloudPlatformProjectId     = flag.String("project", "", "Google Cloud Platform Project ID")
-	credentialsFilePath        = flag.String("credentials_file", "credentials/credentials.json", "Google Cloud Platform Credentials File")
-	firestoreClient            *firestore.Client
-	firestoreAliasesCollection = "aliases"
-	firestoreConnected         bool
+	cloudPlatformProjectId        = flag.String("project", "", "Google Cloud Platform Project ID")
+	credentialsFilePath           = flag.String("credentials_file", "credentials/credentials.json", "Google Cloud Platform Credentials File")
+	firestoreClient               *firestore.Client
+	firestoreAliasesCollection    = "aliases"
+	firestoreAlertsCollection     = "alerts"
+	firestoreWatchlistsCollection = "watchlists"
+	firestorePortfoliosCollection = "portfolios"
+	firestoreTradesCollection     = "trades"
+	firestoreEarningsCollection   = "earningsDigests"
+	firestoreDigestsCollection    = "digests"
+	firestoreGuildsCollection     = "guildSettings"
+	firestoreConnected            bool
 )
 
 func Init() {
@@ -66,25 +74,88 @@ func connectWithExplicitCredentials(ctx context.Context) (*firestore.Client, err
 	return firestoreClient, nil
 }
 
-func GetAliases(ctx context.Context) (map[string][]string, error) {
-	if !firestoreConnected {
-		return nil, errors.New("firestore not connected")
-	}
+// Alias is a named list of assets. Aliases with no GuildID are global and are
+// used by any guild that doesn't have its own alias of the same name.
+type Alias struct {
+	// ID is the Firestore document ID and isn't stored in the document.
+	ID string `firestore:"-"`
 
-	documents, err := getDocumentsInCollection(ctx, firestoreAliasesCollection)
+	Alias  string   `firestore:"alias"`
+	Assets []string `firestore:"assets"`
+
+	GuildID   string    `firestore:"guild"`
+	CreatorID string    `firestore:"creator"`
+	Created   time.Time `firestore:"created"`
+	Updated   time.Time `firestore:"updated"`
+}
+
+// IsGlobal reports whether the alias is visible to every guild.
+func (a *Alias) IsGlobal() bool {
+	return a.GuildID == ""
+}
+
+// GetAliases returns the assets of every alias visible to a guild, keyed by alias.
+func GetAliases(ctx context.Context, guildID string) (map[string][]string, error) {
+	aliases, err := GetAliasesInScope(ctx, guildID)
 	if err != nil {
 		return nil, err
 	}
 
 	aliasMap := make(map[string][]string)
-	for _, doc := range documents {
-		alias := strings.ToUpper(doc["alias"].(string))
-		assets := interfaceSliceToStringSlice(doc["assets"].([]interface{}))
-		aliasMap[alias] = assets
+	for _, alias := range aliases {
+		aliasMap[alias.Alias] = alias.Assets
 	}
 	return aliasMap, nil
 }
 
+// GetAliasesInScope returns every alias visible to a guild, preferring the guild's
+// own aliases over global aliases of the same name.
+func GetAliasesInScope(ctx context.Context, guildID string) ([]*Alias, error) {
+	if !firestoreConnected {
+		return nil, errors.New("firestore not connected")
+	}
+
+	docs, err := firestoreClient.Collection(firestoreAliasesCollection).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find documents: %v", err)
+	}
+
+	scoped := make(map[string]*Alias)
+	for _, doc := range docs {
+		alias, err := aliasFromDocument(doc)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		if alias.GuildID != guildID && !alias.IsGlobal() {
+			continue
+		}
+		if existing, ok := scoped[alias.Alias]; ok && !existing.IsGlobal() {
+			continue
+		}
+		scoped[alias.Alias] = alias
+	}
+
+	aliases := make([]*Alias, 0, len(scoped))
+	for _, alias := range scoped {
+		aliases = append(aliases, alias)
+	}
+	return aliases, nil
+}
+
+func aliasFromDocument(doc *firestore.DocumentSnapshot) (*Alias, error) {
+	alias := &Alias{}
+	if err := doc.DataTo(alias); err != nil {
+		return nil, fmt.Errorf("failed to read alias %q: %v", doc.Ref.ID, err)
+	}
+	alias.ID = doc.Ref.ID
+	alias.Alias = strings.ToUpper(alias.Alias)
+	for i, asset := range alias.Assets {
+		alias.Assets[i] = strings.ToUpper(asset)
+	}
+	return alias, nil
+}
+
 func interfaceSliceToStringSlice(islice []interface{}) (ret []string) {
 	for _, v := range islice {
 		ret = append(ret, strings.ToUpper(v.(string)))
@@ -92,23 +163,54 @@ func interfaceSliceToStringSlice(islice []interface{}) (ret []string) {
 	return
 }
 
-func CreateAlias(ctx context.Context, alias string, assets []string) error {
+// SetAlias creates the alias, or overwrites it if it has an ID.
+func SetAlias(ctx context.Context, alias *Alias) error {
 	if !firestoreConnected {
 		return errors.New("firestore not connected")
 	}
 
-	_, _, err := firestoreClient.Collection(firestoreAliasesCollection).Add(ctx, map[string]interface{}{
-		"alias":  alias,
-		"assets": assets,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to create alias: %v", err)
+	collection := firestoreClient.Collection(firestoreAliasesCollection)
+	if alias.ID == "" {
+		ref, _, err := collection.Add(ctx, alias)
+		if err != nil {
+			return fmt.Errorf("failed to create alias: %v", err)
+		}
+		alias.ID = ref.ID
+		return nil
+	}
+
+	if _, err := collection.Doc(alias.ID).Set(ctx, alias); err != nil {
+		return fmt.Errorf("failed to update alias: %v", err)
 	}
 
 	return nil
 }
 
-func GetAlias(ctx context.Context, alias string) ([]string, error) {
+// GetAlias returns the alias visible to a guild, checking the guild's own aliases before global ones.
+func GetAlias(ctx context.Context, guildID string, alias string) (*Alias, error) {
+	if guildID != "" {
+		scoped, err := GetScopedAlias(ctx, guildID, alias)
+		if err != nil {
+			return nil, err
+		}
+		if scoped != nil {
+			return scoped, nil
+		}
+	}
+
+	global, err := GetScopedAlias(ctx, "", alias)
+	if err != nil {
+		return nil, err
+	}
+	if global == nil {
+		return nil, fmt.Errorf("alias %q not found", alias)
+	}
+	return global, nil
+}
+
+// GetScopedAlias returns the alias defined in exactly the given guild (or globally if guildID is empty),
+// or nil if there isn't one.
+func GetScopedAlias(ctx context.Context, guildID string, alias string) (*Alias, error) {
 	if !firestoreConnected {
 		return nil, errors.New("firestore not connected")
 	}
@@ -119,48 +221,499 @@ func GetAlias(ctx context.Context, alias string) ([]string, error) {
 	}
 
 	for _, doc := range docs {
-		if doc.Data()["alias"] == strings.ToUpper(alias) {
-			return interfaceSliceToStringSlice(doc.Data()["assets"].([]interface{})), nil
+		a, err := aliasFromDocument(doc)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		if a.GuildID == guildID {
+			return a, nil
 		}
 	}
 
-	return nil, fmt.Errorf("alias %q not found", alias)
+	return nil, nil
 }
 
-func DeleteAlias(ctx context.Context, alias string) error {
+func DeleteAlias(ctx context.Context, alias *Alias) error {
 	if !firestoreConnected {
 		return errors.New("firestore not connected")
 	}
 
-	docs, err := firestoreClient.Collection(firestoreAliasesCollection).Where("alias", "==", alias).Documents(ctx).GetAll()
+	if _, err := firestoreClient.Collection(firestoreAliasesCollection).Doc(alias.ID).Delete(ctx); err != nil {
+		return fmt.Errorf("failed to delete alias: %v", err)
+	}
+
+	return nil
+}
+
+// Alert is a price alert on a single ticker.
+type Alert struct {
+	// ID is the Firestore document ID and isn't stored in the document.
+	ID string `firestore:"-"`
+
+	Ticker    string  `firestore:"ticker"`
+	Condition string  `firestore:"condition"`
+	Target    float64 `firestore:"target"`
+	// Reference is the price that percentage conditions are measured from.
+	Reference float64 `firestore:"reference"`
+	// Currency is the currency of the ticker's prices. Empty is USD.
+	Currency string `firestore:"currency"`
+	// Repeat alerts are re-armed after firing instead of being deleted.
+	Repeat bool `firestore:"repeat"`
+	// Armed is false while a repeating alert waits for its condition to clear.
+	Armed bool `firestore:"armed"`
+	DM    bool `firestore:"dm"`
+
+	GuildID   string    `firestore:"guild"`
+	ChannelID string    `firestore:"channel"`
+	UserID    string    `firestore:"user"`
+	Created   time.Time `firestore:"created"`
+}
+
+func CreateAlert(ctx context.Context, alert *Alert) (string, error) {
+	if !firestoreConnected {
+		return "", errors.New("firestore not connected")
+	}
+
+	ref, _, err := firestoreClient.Collection(firestoreAlertsCollection).Add(ctx, alert)
+	if err != nil {
+		return "", fmt.Errorf("failed to create alert: %v", err)
+	}
+
+	return ref.ID, nil
+}
+
+// GetAlerts returns every alert, or only the alerts created by userID if it is set.
+func GetAlerts(ctx context.Context, userID string) ([]*Alert, error) {
+	if !firestoreConnected {
+		return nil, errors.New("firestore not connected")
+	}
+
+	query := firestoreClient.Collection(firestoreAlertsCollection).Query
+	if userID != "" {
+		query = query.Where("user", "==", userID)
+	}
+	docs, err := query.Documents(ctx).GetAll()
 	if err != nil {
-		return fmt.Errorf("failed to find aliases: %v", err)
+		return nil, fmt.Errorf("failed to find alerts: %v", err)
 	}
 
+	alerts := make([]*Alert, 0, len(docs))
 	for _, doc := range docs {
-		if doc.Data()["alias"] == alias {
-			doc.Ref.Delete(ctx)
-			return nil
+		alert := &Alert{}
+		if err := doc.DataTo(alert); err != nil {
+			log.Printf("failed to read alert %q, skipping: %v", doc.Ref.ID, err)
+			continue
 		}
+		alert.ID = doc.Ref.ID
+		alerts = append(alerts, alert)
+	}
+	return alerts, nil
+}
+
+func UpdateAlert(ctx context.Context, alert *Alert) error {
+	if !firestoreConnected {
+		return errors.New("firestore not connected")
+	}
+
+	if _, err := firestoreClient.Collection(firestoreAlertsCollection).Doc(alert.ID).Set(ctx, alert); err != nil {
+		return fmt.Errorf("failed to update alert: %v", err)
 	}
 
 	return nil
 }
 
-func getDocumentsInCollection(ctx context.Context, collection string) ([]map[string]interface{}, error) {
+// DeleteAlert deletes an alert if it was created by userID.
+func DeleteAlert(ctx context.Context, id string, userID string) error {
+	if !firestoreConnected {
+		return errors.New("firestore not connected")
+	}
+
+	ref := firestoreClient.Collection(firestoreAlertsCollection).Doc(id)
+	doc, err := ref.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("alert %q not found", id)
+	}
+	if doc.Data()["user"] != userID {
+		return fmt.Errorf("alert %q belongs to another user", id)
+	}
+
+	if _, err := ref.Delete(ctx); err != nil {
+		return fmt.Errorf("failed to delete alert: %v", err)
+	}
+
+	return nil
+}
+
+// GetWatchlist returns the assets on a user's watchlist.
+func GetWatchlist(ctx context.Context, userID string) ([]string, error) {
 	if !firestoreConnected {
 		return nil, errors.New("firestore not connected")
 	}
 
-	docs, err := firestoreClient.Collection(collection).Documents(ctx).GetAll()
+	doc, err := firestoreClient.Collection(firestoreWatchlistsCollection).Doc(userID).Get(ctx)
+	if doc != nil && !doc.Exists() {
+		return nil, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to find documents: %v", err)
+		return nil, fmt.Errorf("failed to get watchlist: %v", err)
+	}
+
+	assets, ok := doc.Data()["assets"].([]interface{})
+	if !ok {
+		return nil, nil
 	}
+	return interfaceSliceToStringSlice(assets), nil
+}
 
-	documents := make([]map[string]interface{}, 0)
+func AddToWatchlist(ctx context.Context, userID string, assets []string) error {
+	if !firestoreConnected {
+		return errors.New("firestore not connected")
+	}
+
+	_, err := firestoreClient.Collection(firestoreWatchlistsCollection).Doc(userID).Set(ctx, map[string]interface{}{
+		"assets": firestore.ArrayUnion(stringSliceToInterfaceSlice(assets)...),
+	}, firestore.MergeAll)
+	if err != nil {
+		return fmt.Errorf("failed to add to watchlist: %v", err)
+	}
+
+	return nil
+}
+
+func RemoveFromWatchlist(ctx context.Context, userID string, assets []string) error {
+	if !firestoreConnected {
+		return errors.New("firestore not connected")
+	}
+
+	_, err := firestoreClient.Collection(firestoreWatchlistsCollection).Doc(userID).Set(ctx, map[string]interface{}{
+		"assets": firestore.ArrayRemove(stringSliceToInterfaceSlice(assets)...),
+	}, firestore.MergeAll)
+	if err != nil {
+		return fmt.Errorf("failed to remove from watchlist: %v", err)
+	}
+
+	return nil
+}
+
+// Portfolio is a user's paper trading account within a guild.
+type Portfolio struct {
+	GuildID      string               `firestore:"guild"`
+	UserID       string               `firestore:"user"`
+	Cash         float64              `firestore:"cash"`
+	StartingCash float64              `firestore:"startingCash"`
+	Positions    map[string]*Position `firestore:"positions"`
+	Created      time.Time            `firestore:"created"`
+	Updated      time.Time            `firestore:"updated"`
+}
+
+// Position is a holding of a single asset in a Portfolio.
+type Position struct {
+	Quantity float64 `firestore:"quantity"`
+	// CostBasis is the total amount paid for the current quantity.
+	CostBasis float64 `firestore:"costBasis"`
+}
+
+// Trade is a record of a single paper trade.
+type Trade struct {
+	GuildID  string    `firestore:"guild"`
+	UserID   string    `firestore:"user"`
+	Ticker   string    `firestore:"ticker"`
+	Side     string    `firestore:"side"`
+	Quantity float64   `firestore:"quantity"`
+	Price    float64   `firestore:"price"`
+	Time     time.Time `firestore:"time"`
+}
+
+// GetPortfolio returns a user's portfolio in a guild, or nil if they haven't traded there.
+func GetPortfolio(ctx context.Context, guildID string, userID string) (*Portfolio, error) {
+	if !firestoreConnected {
+		return nil, errors.New("firestore not connected")
+	}
+
+	doc, err := portfolioRef(guildID, userID).Get(ctx)
+	if doc != nil && !doc.Exists() {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get portfolio: %v", err)
+	}
+
+	portfolio := &Portfolio{}
+	if err := doc.DataTo(portfolio); err != nil {
+		return nil, fmt.Errorf("failed to read portfolio: %v", err)
+	}
+	return portfolio, nil
+}
+
+// GetPortfolios returns every portfolio in a guild.
+func GetPortfolios(ctx context.Context, guildID string) ([]*Portfolio, error) {
+	if !firestoreConnected {
+		return nil, errors.New("firestore not connected")
+	}
+
+	docs, err := firestoreClient.Collection(firestorePortfoliosCollection).Where("guild", "==", guildID).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find portfolios: %v", err)
+	}
+
+	portfolios := make([]*Portfolio, 0, len(docs))
 	for _, doc := range docs {
-		documents = append(documents, doc.Data())
+		portfolio := &Portfolio{}
+		if err := doc.DataTo(portfolio); err != nil {
+			log.Printf("failed to read portfolio %q, skipping: %v", doc.Ref.ID, err)
+			continue
+		}
+		portfolios = append(portfolios, portfolio)
 	}
+	return portfolios, nil
+}
+
+// UpdatePortfolio atomically applies update to a user's portfolio and records the trade it returns.
+// Portfolios that don't exist yet are passed to update with a zero Created time.
+func UpdatePortfolio(ctx context.Context, guildID string, userID string, update func(p *Portfolio) (*Trade, error)) error {
+	if !firestoreConnected {
+		return errors.New("firestore not connected")
+	}
+
+	ref := portfolioRef(guildID, userID)
+	return firestoreClient.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		docs, err := tx.GetAll([]*firestore.DocumentRef{ref})
+		if err != nil {
+			return fmt.Errorf("failed to get portfolio: %v", err)
+		}
+
+		portfolio := &Portfolio{GuildID: guildID, UserID: userID}
+		if docs[0].Exists() {
+			if err := docs[0].DataTo(portfolio); err != nil {
+				return fmt.Errorf("failed to read portfolio: %v", err)
+			}
+		}
+		if portfolio.Positions == nil {
+			portfolio.Positions = make(map[string]*Position)
+		}
+
+		trade, err := update(portfolio)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Set(ref, portfolio); err != nil {
+			return fmt.Errorf("failed to update portfolio: %v", err)
+		}
+		if trade != nil {
+			if err := tx.Create(firestoreClient.Collection(firestoreTradesCollection).NewDoc(), trade); err != nil {
+				return fmt.Errorf("failed to record trade: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+func portfolioRef(guildID string, userID string) *firestore.DocumentRef {
+	return firestoreClient.Collection(firestorePortfoliosCollection).Doc(fmt.Sprintf("%s_%s", guildID, userID))
+}
+
+// EarningsDigest is a channel's subscription to morning digests of its guild's upcoming earnings.
+type EarningsDigest struct {
+	GuildID   string    `firestore:"guild"`
+	ChannelID string    `firestore:"channel"`
+	CreatorID string    `firestore:"creator"`
+	Created   time.Time `firestore:"created"`
+	// LastSent keeps digests from being sent twice in a day across restarts.
+	LastSent time.Time `firestore:"lastSent"`
+}
+
+// SetEarningsDigest creates or replaces the earnings digest subscription of a channel.
+func SetEarningsDigest(ctx context.Context, digest *EarningsDigest) error {
+	if !firestoreConnected {
+		return errors.New("firestore not connected")
+	}
+
+	if _, err := firestoreClient.Collection(firestoreEarningsCollection).Doc(digest.ChannelID).Set(ctx, digest); err != nil {
+		return fmt.Errorf("failed to set earnings digest: %v", err)
+	}
+	return nil
+}
+
+// GetEarningsDigests returns every channel's earnings digest subscription.
+func GetEarningsDigests(ctx context.Context) ([]*EarningsDigest, error) {
+	if !firestoreConnected {
+		return nil, errors.New("firestore not connected")
+	}
+
+	docs, err := firestoreClient.Collection(firestoreEarningsCollection).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get earnings digests: %v", err)
+	}
+
+	var digests []*EarningsDigest
+	for _, doc := range docs {
+		var digest EarningsDigest
+		if err := doc.DataTo(&digest); err != nil {
+			log.Printf("failed to read earnings digest %q: %v", doc.Ref.ID, err)
+			continue
+		}
+		digests = append(digests, &digest)
+	}
+	return digests, nil
+}
+
+// DeleteEarningsDigest removes the earnings digest subscription of a channel. It returns false if there wasn't one.
+func DeleteEarningsDigest(ctx context.Context, channelID string) (bool, error) {
+	if !firestoreConnected {
+		return false, errors.New("firestore not connected")
+	}
+
+	ref := firestoreClient.Collection(firestoreEarningsCollection).Doc(channelID)
+	doc, err := ref.Get(ctx)
+	if doc != nil && !doc.Exists() {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get earnings digest: %v", err)
+	}
+	if _, err := ref.Delete(ctx); err != nil {
+		return false, fmt.Errorf("failed to delete earnings digest: %v", err)
+	}
+	return true, nil
+}
 
-	return documents, nil
+// Digest is a channel's subscription to a recurring quote of a set of tickers.
+type Digest struct {
+	ID        string `firestore:"-"`
+	GuildID   string `firestore:"guild"`
+	ChannelID string `firestore:"channel"`
+	CreatorID string `firestore:"creator"`
+	// Tickers may include aliases, which are expanded each time the digest is sent.
+	Tickers []string `firestore:"tickers"`
+	// Time is the time of day to send the digest, e.g. "09:35", in Timezone.
+	Time     string `firestore:"time"`
+	Timezone string `firestore:"timezone"`
+	// Daily digests are also sent on days the stock market is closed.
+	Daily    bool      `firestore:"daily"`
+	Created  time.Time `firestore:"created"`
+	LastSent time.Time `firestore:"lastSent"`
+}
+
+// CreateDigest stores a new digest and returns its ID.
+func CreateDigest(ctx context.Context, digest *Digest) (string, error) {
+	if !firestoreConnected {
+		return "", errors.New("firestore not connected")
+	}
+
+	ref, _, err := firestoreClient.Collection(firestoreDigestsCollection).Add(ctx, digest)
+	if err != nil {
+		return "", fmt.Errorf("failed to create digest: %v", err)
+	}
+	return ref.ID, nil
+}
+
+// GetDigests returns the digests of a channel, or every digest if channelID is empty.
+func GetDigests(ctx context.Context, channelID string) ([]*Digest, error) {
+	if !firestoreConnected {
+		return nil, errors.New("firestore not connected")
+	}
+
+	query := firestoreClient.Collection(firestoreDigestsCollection).Query
+	if channelID != "" {
+		query = query.Where("channel", "==", channelID)
+	}
+	docs, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get digests: %v", err)
+	}
+
+	var digests []*Digest
+	for _, doc := range docs {
+		var digest Digest
+		if err := doc.DataTo(&digest); err != nil {
+			log.Printf("failed to read digest %q: %v", doc.Ref.ID, err)
+			continue
+		}
+		digest.ID = doc.Ref.ID
+		digests = append(digests, &digest)
+	}
+	return digests, nil
+}
+
+// UpdateDigest replaces a stored digest.
+func UpdateDigest(ctx context.Context, digest *Digest) error {
+	if !firestoreConnected {
+		return errors.New("firestore not connected")
+	}
+
+	if _, err := firestoreClient.Collection(firestoreDigestsCollection).Doc(digest.ID).Set(ctx, digest); err != nil {
+		return fmt.Errorf("failed to update digest: %v", err)
+	}
+	return nil
+}
+
+// DeleteDigest deletes a digest if it belongs to the channel.
+func DeleteDigest(ctx context.Context, id string, channelID string) error {
+	if !firestoreConnected {
+		return errors.New("firestore not connected")
+	}
+
+	ref := firestoreClient.Collection(firestoreDigestsCollection).Doc(id)
+	doc, err := ref.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("digest %q not found", id)
+	}
+	if doc.Data()["channel"] != channelID {
+		return fmt.Errorf("digest %q belongs to another channel", id)
+	}
+
+	if _, err := ref.Delete(ctx); err != nil {
+		return fmt.Errorf("failed to delete digest: %v", err)
+	}
+	return nil
+}
+
+// GuildSettings are a guild's preferences for how the bot responds in it.
+type GuildSettings struct {
+	GuildID string `firestore:"guild"`
+	// Currency is the code of the currency quotes are shown in, or "" for USD.
+	Currency string `firestore:"currency"`
+}
+
+// GetGuildSettings returns the settings of a guild. Guilds that haven't changed any get default settings.
+func GetGuildSettings(ctx context.Context, guildID string) (*GuildSettings, error) {
+	if !firestoreConnected {
+		return nil, errors.New("firestore not connected")
+	}
+
+	doc, err := firestoreClient.Collection(firestoreGuildsCollection).Doc(guildID).Get(ctx)
+	if doc != nil && !doc.Exists() {
+		return &GuildSettings{GuildID: guildID}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get guild settings: %v", err)
+	}
+
+	var settings GuildSettings
+	if err := doc.DataTo(&settings); err != nil {
+		return nil, fmt.Errorf("failed to read guild settings: %v", err)
+	}
+	return &settings, nil
+}
+
+// SetGuildSettings creates or replaces the settings of a guild.
+func SetGuildSettings(ctx context.Context, settings *GuildSettings) error {
+	if !firestoreConnected {
+		return errors.New("firestore not connected")
+	}
+
+	if _, err := firestoreClient.Collection(firestoreGuildsCollection).Doc(settings.GuildID).Set(ctx, settings); err != nil {
+		return fmt.Errorf("failed to set guild settings: %v", err)
+	}
+	return nil
+}
+
+func stringSliceToInterfaceSlice(s []string) []interface{} {
+	ret := make([]interface{}, len(s))
+	for i, v := range s {
+		ret[i] = v
+	}
+	return ret
 }
@@ -0,0 +1,235 @@
+package forexlib
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Finnhub-Stock-API/finnhub-go"
+	"github.com/JoeParrinello/brokerbot/cachelib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/quotelib"
+	"github.com/JoeParrinello/brokerbot/ratelimitlib"
+)
+
+const (
+	// Pairs are quoted to a tenth of a pip, except yen pairs whose pips are a hundred times larger.
+	pairPrecision    = 5
+	yenPairPrecision = 3
+	// Commodities and indices are quoted to the cent.
+	instrumentPrecision = 2
+
+	// Forex doesn't trade on weekends, so fetch this much extra history to fill a timeframe.
+	weekendLookback = 3 * 24 * time.Hour
+	// quoteLookback covers a weekend and a holiday, so quotes always have a previous close.
+	quoteLookback = 7 * 24 * time.Hour
+)
+
+var (
+	forexResolutions = map[quotelib.Timeframe]string{
+		quotelib.OneDay:    "5",
+		quotelib.FiveDays:  "30",
+		quotelib.OneMonth:  "60",
+		quotelib.SixMonths: "D",
+		quotelib.OneYear:   "D",
+		quotelib.FiveYears: "W",
+	}
+
+	currencyNames = map[string]string{
+		"USD": "US Dollar",
+		"EUR": "Euro",
+		"GBP": "British Pound",
+		"JPY": "Japanese Yen",
+		"CHF": "Swiss Franc",
+		"CAD": "Canadian Dollar",
+		"AUD": "Australian Dollar",
+		"NZD": "New Zealand Dollar",
+		"CNH": "Chinese Yuan",
+		"HKD": "Hong Kong Dollar",
+		"SGD": "Singapore Dollar",
+		"SEK": "Swedish Krona",
+		"NOK": "Norwegian Krone",
+		"MXN": "Mexican Peso",
+		"ZAR": "South African Rand",
+		"TRY": "Turkish Lira",
+	}
+
+	quoteCache = cachelib.New("forex quotes", cachelib.PriceTTL)
+)
+
+// FinnhubProvider is a quotelib.QuoteProvider for forex pairs, e.g. "EURUSD", and other OANDA
+// instruments, e.g. "XAU_USD" for gold, backed by Finnhub's OANDA rates.
+type FinnhubProvider struct {
+	client *finnhub.DefaultApiService
+}
+
+// NewFinnhubProvider returns a FinnhubProvider using the given client.
+func NewFinnhubProvider(client *finnhub.DefaultApiService) *FinnhubProvider {
+	return &FinnhubProvider{client: client}
+}
+
+// GetQuote implements quotelib.QuoteProvider.
+func (p *FinnhubProvider) GetQuote(ctx context.Context, pair string) (*messagelib.TickerValue, error) {
+	return GetQuoteForPair(ctx, p.client, pair)
+}
+
+// GetCandles implements quotelib.QuoteProvider.
+func (p *FinnhubProvider) GetCandles(ctx context.Context, pair string, timeframe quotelib.Timeframe) ([]*quotelib.Candle, error) {
+	return GetCandlesForPair(ctx, p.client, pair, timeframe)
+}
+
+// GetName implements quotelib.QuoteProvider.
+func (p *FinnhubProvider) GetName(ctx context.Context, pair string) (string, error) {
+	return GetNameForPair(pair), nil
+}
+
+// GetQuoteIn implements quotelib.CurrencyProvider. A pair is only quoted in its own quote currency.
+func (p *FinnhubProvider) GetQuoteIn(ctx context.Context, pair string, currency string) (*messagelib.TickerValue, error) {
+	if _, quote, ok := SplitPair(pair); !ok || quote != currency {
+		return nil, quotelib.ErrNoMarket
+	}
+	return p.GetQuote(ctx, pair)
+}
+
+// GetCandlesIn implements quotelib.CurrencyProvider. A pair is only quoted in its own quote currency.
+func (p *FinnhubProvider) GetCandlesIn(ctx context.Context, pair string, currency string, timeframe quotelib.Timeframe) ([]*quotelib.Candle, error) {
+	if _, quote, ok := SplitPair(pair); !ok || quote != currency {
+		return nil, quotelib.ErrNoMarket
+	}
+	return p.GetCandles(ctx, pair, timeframe)
+}
+
+// SplitPair returns the base and quote currencies of a pair, e.g. "EUR" and "USD" for "EURUSD",
+// or the instrument and its quote currency for an OANDA instrument, e.g. "XAU" and "USD" for "XAU_USD".
+func SplitPair(pair string) (string, string, bool) {
+	if i := strings.LastIndex(pair, "_"); i > 0 && i < len(pair)-1 {
+		return pair[:i], pair[i+1:], true
+	}
+	if len(pair) != 6 {
+		return "", "", false
+	}
+	return pair[:3], pair[3:], true
+}
+
+// GetNameForPair returns the name of a pair, e.g. "Euro / US Dollar", or "" if it is unknown.
+func GetNameForPair(pair string) string {
+	if symbol, ok := quotelib.LookupTicker(quotelib.Forex, pair); ok {
+		return symbol.Name
+	}
+	base, quote, ok := SplitPair(pair)
+	if !ok || currencyNames[base] == "" || currencyNames[quote] == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s / %s", currencyNames[base], currencyNames[quote])
+}
+
+// GetQuoteForPair returns the TickerValue for a pair, with its change since the previous daily close.
+func GetQuoteForPair(ctx context.Context, f *finnhub.DefaultApiService, pair string) (*messagelib.TickerValue, error) {
+	base, quote, ok := SplitPair(pair)
+	if !ok {
+		return nil, fmt.Errorf("invalid forex pair %q, expected e.g. EUR/USD", pair)
+	}
+	tickerValue := &messagelib.TickerValue{
+		Ticker:    fmt.Sprintf("%s/%s", base, quote),
+		Currency:  quote,
+		Precision: pairPrecision,
+	}
+	switch {
+	case len(base) != 3 || currencyNames[base] == "":
+		tickerValue.Precision = instrumentPrecision
+	case quote == "JPY":
+		tickerValue.Precision = yenPairPrecision
+	}
+	if symbol, ok := quotelib.LookupTicker(quotelib.Forex, pair); ok {
+		tickerValue.Ticker = symbol.Alias()
+	}
+	if name := GetNameForPair(pair); name != "" {
+		tickerValue.Ticker = fmt.Sprintf("%s (%s)", tickerValue.Ticker, name)
+	}
+
+	candles, err := quoteCache.Get(pair, func() (interface{}, error) {
+		return fetchCandles(ctx, f, pair, "D", quoteLookback)
+	})
+	if err != nil {
+		return nil, err
+	}
+	daily := candles.([]*quotelib.Candle)
+	if len(daily) == 0 {
+		// No candles means OANDA doesn't quote the pair, which is shown like other unknown tickers.
+		return tickerValue, nil
+	}
+	last := daily[len(daily)-1]
+	tickerValue.Value = last.Close
+	tickerValue.LastTrade = last.Time
+	if len(daily) > 1 {
+		previous := daily[len(daily)-2].Close
+		tickerValue.Change = (last.Close - previous) / previous * 100
+	}
+	return tickerValue, nil
+}
+
+// GetCandlesForPair returns candles covering the timeframe for a pair, ending at its last trade.
+func GetCandlesForPair(ctx context.Context, f *finnhub.DefaultApiService, pair string, timeframe quotelib.Timeframe) ([]*quotelib.Candle, error) {
+	resolution, ok := forexResolutions[timeframe]
+	if !ok {
+		return nil, fmt.Errorf("unsupported forex timeframe: %q", timeframe.Name)
+	}
+	candles, err := fetchCandles(ctx, f, pair, resolution, timeframe.Duration+weekendLookback)
+	if err != nil {
+		return nil, err
+	}
+	if len(candles) == 0 {
+		return candles, nil
+	}
+	start := candles[len(candles)-1].Time.Add(-timeframe.Duration)
+	for i, candle := range candles {
+		if !candle.Time.Before(start) {
+			return candles[i:], nil
+		}
+	}
+	return candles, nil
+}
+
+func fetchCandles(ctx context.Context, f *finnhub.DefaultApiService, pair string, resolution string, lookback time.Duration) ([]*quotelib.Candle, error) {
+	base, quote, ok := SplitPair(pair)
+	if !ok {
+		return nil, fmt.Errorf("invalid forex pair %q, expected e.g. EUR/USD", pair)
+	}
+	symbol := fmt.Sprintf("OANDA:%s_%s", base, quote)
+
+	now := time.Now()
+	var candles finnhub.ForexCandles
+	err := ratelimitlib.Finnhub.Do(ctx, func() (res *http.Response, err error) {
+		candles, res, err = f.ForexCandles(ctx, symbol, resolution, now.Add(-lookback).Unix(), now.Unix())
+		return res, err
+	})
+	if err != nil {
+		log.Printf("failed to request forex candle: %v", err)
+		return nil, err
+	}
+
+	ret := make([]*quotelib.Candle, 0, len(candles.T))
+	for i, t := range candles.T {
+		if i >= len(candles.O) || i >= len(candles.H) || i >= len(candles.L) || i >= len(candles.C) {
+			break
+		}
+		var volume float32
+		if i < len(candles.V) {
+			volume = candles.V[i]
+		}
+		ret = append(ret, &quotelib.Candle{
+			// The Finnhub client decodes forex timestamps as float32, which rounds them to a couple of minutes.
+			Time:   time.Unix(int64(math.Round(float64(t))), 0),
+			Open:   candles.O[i],
+			High:   candles.H[i],
+			Low:    candles.L[i],
+			Close:  candles.C[i],
+			Volume: volume,
+		})
+	}
+	return ret, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JoeParrinello/brokerbot/commandlib"
+	"github.com/JoeParrinello/brokerbot/marketlib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/quotelib"
+)
+
+// overviewTickers are the markets in the indices overview, in display order.
+var overviewTickers = []string{"SPX", "NDX", "DJI", "RUT", "VIX", "/ES", "/NQ", "/GC", "/CL"}
+
+func init() {
+	commandlib.Register(&commandlib.Command{
+		Name:        "indices",
+		Description: "Show an overview of the major indices, futures and commodities",
+		Handler:     handleIndices,
+	})
+}
+
+func handleIndices(ctx context.Context, r *commandlib.Request) error {
+	quotes, failedTickers := quotelib.GetQuotes(ctx, overviewTickers)
+	if len(quotes) == 0 {
+		return fmt.Errorf("failed to get quotes for: %s (See logs)", strings.Join(failedTickers, ", "))
+	}
+	description := marketlib.GetStatus(time.Now()).Describe()
+	if len(failedTickers) > 0 {
+		description = fmt.Sprintf("%s\nCouldn't get quotes for: %s (See logs)", description, strings.Join(failedTickers, ", "))
+	}
+	messagelib.ReplyMessageEmbed(r.Reply, messagelib.CreateOverviewEmbed("Market Overview", description, quotes))
+	return nil
+}
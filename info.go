@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JoeParrinello/brokerbot/commandlib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/quotelib"
+)
+
+func init() {
+	commandlib.Register(&commandlib.Command{
+		Name:        "info",
+		Description: "Show the day's trading, valuation and 52 week range of a ticker",
+		Args: []commandlib.Arg{
+			{Name: "ticker", Description: "Ticker to look up, e.g. AAPL or $BTC", Complete: completeTicker},
+		},
+		Handler: handleInfo,
+	})
+}
+
+func handleInfo(ctx context.Context, r *commandlib.Request) error {
+	tickers := messagelib.CanonicalizeMessage(messagelib.RemoveMentions(r.Args[:1]))
+	if len(tickers) == 0 {
+		return commandlib.ErrUsage
+	}
+	ticker, class := quotelib.ParseTicker(tickers[0])
+	provider, ok := quotelib.GetProvider(class)
+	if !ok {
+		return fmt.Errorf("no quote provider for %s ticker %q", class, ticker)
+	}
+	detailProvider, ok := provider.(quotelib.DetailProvider)
+	if !ok {
+		return fmt.Errorf("details aren't available for %s tickers", class)
+	}
+	detail, err := detailProvider.GetDetail(ctx, ticker)
+	if err != nil {
+		return fmt.Errorf("failed to get details for %s ticker %q: %v", class, ticker, err)
+	}
+	messagelib.ReplyMessageEmbed(r.Reply, messagelib.CreateDetailEmbed(detail))
+	return nil
+}
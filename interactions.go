@@ -0,0 +1,133 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	"github.com/JoeParrinello/brokerbot/commandlib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/statuszlib"
+	"github.com/bwmarrin/discordgo"
+)
+
+const slashCommandName = "stonks"
+
+// registerSlashCommands replaces the bot's application commands with one built from the command registry.
+func registerSlashCommands(s *discordgo.Session, guildID string) error {
+	_, err := s.ApplicationCommandBulkOverwrite(s.State.User.ID, guildID, []*discordgo.ApplicationCommand{
+		commandlib.ApplicationCommand(slashCommandName, "Stock and crypto quotes"),
+	})
+	return err
+}
+
+func handleInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		handleSlashCommand(s, i.Interaction)
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		handleAutocomplete(s, i.Interaction)
+	case discordgo.InteractionMessageComponent:
+		handleComponent(s, i.Interaction)
+	}
+}
+
+func handleSlashCommand(s *discordgo.Session, i *discordgo.Interaction) {
+	data := i.ApplicationCommandData()
+	if data.Name != slashCommandName {
+		return
+	}
+
+	statuszlib.RecordRequest()
+
+	// Quotes can take longer than the 3 seconds Discord allows for a response, so acknowledge first.
+	if err := s.InteractionRespond(i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	}); err != nil {
+		log.Printf("failed to defer interaction response: %v", err)
+		statuszlib.RecordError()
+		return
+	}
+
+	reply := &messagelib.InteractionReplier{Session: s, Interaction: i}
+	cmd, args, ok := commandlib.ParseInteraction(data.Options)
+	if !ok {
+		messagelib.ReplyMessage(reply, getHelpMessage())
+		return
+	}
+	commandlib.Dispatch(ctx, cmd, &commandlib.Request{
+		Session:   s,
+		ChannelID: i.ChannelID,
+		GuildID:   i.GuildID,
+		UserID:    interactionUser(i).ID,
+		Args:      args,
+		Reply:     reply,
+	})
+}
+
+// handleComponent runs the command of a clicked messagelib.CommandButton, replying with a new
+// message or by editing the button's message in place.
+func handleComponent(s *discordgo.Session, i *discordgo.Interaction) {
+	command, update, ok := messagelib.ParseCommandButton(i.MessageComponentData().CustomID)
+	if !ok {
+		return
+	}
+
+	statuszlib.RecordRequest()
+
+	responseType := discordgo.InteractionResponseDeferredChannelMessageWithSource
+	if update {
+		responseType = discordgo.InteractionResponseDeferredMessageUpdate
+	}
+	if err := s.InteractionRespond(i, &discordgo.InteractionResponse{
+		Type: responseType,
+	}); err != nil {
+		log.Printf("failed to defer interaction response: %v", err)
+		statuszlib.RecordError()
+		return
+	}
+
+	var reply messagelib.Replier = &messagelib.InteractionReplier{Session: s, Interaction: i}
+	if update {
+		reply = &messagelib.MessageUpdater{Session: s, Interaction: i}
+	}
+	if command == "" {
+		messagelib.ReplyMessage(reply, "This button has expired, run the command again instead.")
+		return
+	}
+	cmd, args, ok := commandlib.Parse(strings.Fields(command))
+	if !ok {
+		messagelib.ReplyMessage(reply, getHelpMessage())
+		return
+	}
+	commandlib.Dispatch(ctx, cmd, &commandlib.Request{
+		Session:   s,
+		ChannelID: i.ChannelID,
+		GuildID:   i.GuildID,
+		UserID:    interactionUser(i).ID,
+		Args:      args,
+		Reply:     reply,
+	})
+}
+
+func handleAutocomplete(s *discordgo.Session, i *discordgo.Interaction) {
+	if err := s.InteractionRespond(i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: commandlib.Autocomplete(ctx, &commandlib.Request{
+				Session:   s,
+				ChannelID: i.ChannelID,
+				GuildID:   i.GuildID,
+				UserID:    interactionUser(i).ID,
+			}, i.ApplicationCommandData().Options),
+		},
+	}); err != nil {
+		log.Printf("failed to respond to autocomplete: %v", err)
+	}
+}
+
+func interactionUser(i *discordgo.Interaction) *discordgo.User {
+	if i.Member != nil {
+		return i.Member.User
+	}
+	return i.User
+}
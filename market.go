@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/JoeParrinello/brokerbot/commandlib"
+	"github.com/JoeParrinello/brokerbot/marketlib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+)
+
+const upcomingHolidays = 3
+
+func init() {
+	commandlib.Register(&commandlib.Command{
+		Name:        "market",
+		Description: "Show whether the US stock market is open and its hours",
+		Handler:     handleMarket,
+	})
+}
+
+func handleMarket(ctx context.Context, r *commandlib.Request) error {
+	now := time.Now()
+	status := marketlib.GetStatus(now)
+
+	// Show today's hours until after hours trading ends, then the next trading day's.
+	day := status.Day
+	if !day.IsTradingDay() || !now.Before(day.AfterHoursClose()) {
+		day = marketlib.NextTradingDay(now.AddDate(0, 0, 1))
+	}
+
+	var holidays []*messagelib.MarketHoliday
+	for _, holiday := range marketlib.UpcomingHolidays(now, upcomingHolidays) {
+		holidays = append(holidays, &messagelib.MarketHoliday{
+			Name:       holiday.Holiday,
+			Date:       holiday.Date,
+			EarlyClose: holiday.EarlyClose,
+		})
+	}
+
+	messagelib.ReplyMessageEmbed(r.Reply, messagelib.CreateMarketEmbed(&messagelib.MarketStatus{
+		Name:    "US stock market",
+		Session: status.Describe(),
+		Sessions: []*messagelib.MarketSession{
+			{Name: marketlib.PreMarket.String(), Start: day.PreMarketOpen(), End: day.Open()},
+			{Name: "Regular hours", Start: day.Open(), End: day.Close()},
+			{Name: marketlib.AfterHours.String(), Start: day.Close(), End: day.AfterHoursClose()},
+		},
+		NextOpen:  status.NextOpen,
+		NextClose: status.NextClose,
+		Holidays:  holidays,
+	}))
+	return nil
+}
@@ -0,0 +1,277 @@
+package marketlib
+
+import (
+	"fmt"
+	"time"
+
+	// Embed the time zone database so market hours don't depend on the host having one.
+	_ "time/tzdata"
+)
+
+// Session is a trading session of the US stock market.
+type Session int
+
+const (
+	Closed Session = iota
+	PreMarket
+	Regular
+	AfterHours
+)
+
+func (s Session) String() string {
+	switch s {
+	case PreMarket:
+		return "Pre-market"
+	case Regular:
+		return "Market open"
+	case AfterHours:
+		return "After hours"
+	}
+	return "Market closed"
+}
+
+// Session boundaries as offsets from midnight in New York.
+const (
+	preMarketOpen        = 4 * time.Hour
+	regularOpen          = 9*time.Hour + 30*time.Minute
+	regularClose         = 16 * time.Hour
+	earlyClose           = 13 * time.Hour
+	afterHoursClose      = 20 * time.Hour
+	earlyAfterHoursClose = 17 * time.Hour
+
+	// Markets are never closed for longer than a long weekend plus a holiday, so this bounds searches for the next session.
+	maxDaysClosed = 10
+)
+
+var location = mustLoadLocation("America/New_York")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(fmt.Sprintf("failed to load time zone %q: %v", name, err))
+	}
+	return loc
+}
+
+// Location returns the time zone the market's hours are in.
+func Location() *time.Location {
+	return location
+}
+
+// Day is the trading schedule of a single date.
+type Day struct {
+	// Date is midnight at the start of the day in New York.
+	Date time.Time
+	// Holiday is the name of the holiday the market is closed or closes early for, if any.
+	Holiday string
+	// EarlyClose is set when the market closes at 1:00 PM.
+	EarlyClose bool
+}
+
+// GetDay returns the trading schedule of the day containing t.
+func GetDay(t time.Time) *Day {
+	t = t.In(location)
+	day := &Day{Date: time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)}
+	for _, holiday := range getHolidays(t.Year()) {
+		if holiday.Date.Equal(day.Date) {
+			return holiday
+		}
+	}
+	return day
+}
+
+// IsTradingDay returns true if the market opens on the day.
+func (d *Day) IsTradingDay() bool {
+	if d.Date.Weekday() == time.Saturday || d.Date.Weekday() == time.Sunday {
+		return false
+	}
+	return d.Holiday == "" || d.EarlyClose
+}
+
+// PreMarketOpen returns when pre-market trading starts.
+func (d *Day) PreMarketOpen() time.Time {
+	return d.at(preMarketOpen)
+}
+
+// Open returns when the regular session starts.
+func (d *Day) Open() time.Time {
+	return d.at(regularOpen)
+}
+
+// Close returns when the regular session ends.
+func (d *Day) Close() time.Time {
+	if d.EarlyClose {
+		return d.at(earlyClose)
+	}
+	return d.at(regularClose)
+}
+
+// AfterHoursClose returns when after hours trading ends.
+func (d *Day) AfterHoursClose() time.Time {
+	if d.EarlyClose {
+		return d.at(earlyAfterHoursClose)
+	}
+	return d.at(afterHoursClose)
+}
+
+func (d *Day) at(offset time.Duration) time.Time {
+	// Add hours and minutes to the calendar date rather than the instant so DST changes don't shift sessions.
+	return time.Date(d.Date.Year(), d.Date.Month(), d.Date.Day(), 0, int(offset/time.Minute), 0, 0, location)
+}
+
+// GetSession returns the session the market is in at t.
+func GetSession(t time.Time) Session {
+	day := GetDay(t)
+	switch {
+	case !day.IsTradingDay():
+		return Closed
+	case t.Before(day.PreMarketOpen()):
+		return Closed
+	case t.Before(day.Open()):
+		return PreMarket
+	case t.Before(day.Close()):
+		return Regular
+	case t.Before(day.AfterHoursClose()):
+		return AfterHours
+	}
+	return Closed
+}
+
+// Status is the state of the market at a point in time.
+type Status struct {
+	Session Session
+	// Day is the schedule of the current day.
+	Day       *Day
+	NextOpen  time.Time
+	NextClose time.Time
+}
+
+// GetStatus returns the state of the market at t.
+func GetStatus(t time.Time) *Status {
+	status := &Status{
+		Session: GetSession(t),
+		Day:     GetDay(t),
+	}
+	for i := 0; i <= maxDaysClosed && (status.NextOpen.IsZero() || status.NextClose.IsZero()); i++ {
+		day := GetDay(t.AddDate(0, 0, i))
+		if !day.IsTradingDay() {
+			continue
+		}
+		if status.NextOpen.IsZero() && day.Open().After(t) {
+			status.NextOpen = day.Open()
+		}
+		if status.NextClose.IsZero() && day.Close().After(t) {
+			status.NextClose = day.Close()
+		}
+	}
+	return status
+}
+
+// Describe returns a short description of the session, naming the holiday if the market is closed for one.
+func (s *Status) Describe() string {
+	if s.Session == Closed && s.Day.Holiday != "" && !s.Day.EarlyClose {
+		return fmt.Sprintf("Market closed for %s", s.Day.Holiday)
+	}
+	return s.Session.String()
+}
+
+// NextTradingDay returns the schedule of the first trading day on or after t.
+func NextTradingDay(t time.Time) *Day {
+	for i := 0; i <= maxDaysClosed; i++ {
+		if day := GetDay(t.AddDate(0, 0, i)); day.IsTradingDay() {
+			return day
+		}
+	}
+	return nil
+}
+
+// UpcomingHolidays returns the next n holidays and early closes on or after t.
+func UpcomingHolidays(t time.Time, n int) []*Day {
+	today := GetDay(t).Date
+	var ret []*Day
+	for year := today.Year(); len(ret) < n; year++ {
+		for _, holiday := range getHolidays(year) {
+			if len(ret) < n && !holiday.Date.Before(today) {
+				ret = append(ret, holiday)
+			}
+		}
+	}
+	return ret
+}
+
+// getHolidays returns the NYSE holidays and early closes of a year, in date order.
+func getHolidays(year int) []*Day {
+	holidays := []*Day{
+		{Date: observed(date(year, time.January, 1)), Holiday: "New Year's Day"},
+		{Date: nthWeekday(year, time.January, time.Monday, 3), Holiday: "Martin Luther King Jr. Day"},
+		{Date: nthWeekday(year, time.February, time.Monday, 3), Holiday: "Washington's Birthday"},
+		{Date: easter(year).AddDate(0, 0, -2), Holiday: "Good Friday"},
+		{Date: lastWeekday(year, time.May, time.Monday), Holiday: "Memorial Day"},
+	}
+	if year >= 2022 {
+		holidays = append(holidays, &Day{Date: observed(date(year, time.June, 19)), Holiday: "Juneteenth"})
+	}
+	if july3 := date(year, time.July, 3); july3.Weekday() >= time.Monday && july3.Weekday() <= time.Thursday {
+		holidays = append(holidays, &Day{Date: july3, Holiday: "Independence Day", EarlyClose: true})
+	}
+	thanksgiving := nthWeekday(year, time.November, time.Thursday, 4)
+	holidays = append(holidays,
+		&Day{Date: observed(date(year, time.July, 4)), Holiday: "Independence Day"},
+		&Day{Date: nthWeekday(year, time.September, time.Monday, 1), Holiday: "Labor Day"},
+		&Day{Date: thanksgiving, Holiday: "Thanksgiving Day"},
+		&Day{Date: thanksgiving.AddDate(0, 0, 1), Holiday: "Thanksgiving Day", EarlyClose: true},
+	)
+	if christmasEve := date(year, time.December, 24); christmasEve.Weekday() >= time.Monday && christmasEve.Weekday() <= time.Thursday {
+		holidays = append(holidays, &Day{Date: christmasEve, Holiday: "Christmas Day", EarlyClose: true})
+	}
+	holidays = append(holidays, &Day{Date: observed(date(year, time.December, 25)), Holiday: "Christmas Day"})
+
+	// New Year's Day isn't observed on the Friday before when it falls on a Saturday.
+	if holidays[0].Date.Year() != year {
+		holidays = holidays[1:]
+	}
+	return holidays
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, location)
+}
+
+// observed moves holidays on a Saturday to the Friday before and on a Sunday to the Monday after.
+func observed(t time.Time) time.Time {
+	switch t.Weekday() {
+	case time.Saturday:
+		return t.AddDate(0, 0, -1)
+	case time.Sunday:
+		return t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	t := date(year, month, 1)
+	offset := (int(weekday) - int(t.Weekday()) + 7) % 7
+	return t.AddDate(0, 0, offset+7*(n-1))
+}
+
+func lastWeekday(year int, month time.Month, weekday time.Weekday) time.Time {
+	t := date(year, month+1, 1).AddDate(0, 0, -1)
+	offset := (int(t.Weekday()) - int(weekday) + 7) % 7
+	return t.AddDate(0, 0, -offset)
+}
+
+// easter returns Easter Sunday using the anonymous Gregorian algorithm.
+func easter(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return date(year, time.Month(month), day)
+}
@@ -0,0 +1,141 @@
+package marketlib
+
+import (
+	"testing"
+	"time"
+)
+
+func at(year int, month time.Month, day int, hour int, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, location)
+}
+
+func TestGetDay(t *testing.T) {
+	tests := []struct {
+		name       string
+		date       time.Time
+		tradingDay bool
+		holiday    string
+		earlyClose bool
+	}{
+		{name: "weekday", date: date(2024, time.March, 11), tradingDay: true},
+		{name: "saturday", date: date(2024, time.March, 9)},
+		{name: "sunday", date: date(2024, time.March, 10)},
+		{name: "new year's day", date: date(2024, time.January, 1), holiday: "New Year's Day"},
+		{name: "new year's day observed monday", date: date(2023, time.January, 2), holiday: "New Year's Day"},
+		{name: "new year's day not observed friday before", date: date(2021, time.December, 31), tradingDay: true},
+		{name: "mlk day", date: date(2024, time.January, 15), holiday: "Martin Luther King Jr. Day"},
+		{name: "washington's birthday", date: date(2024, time.February, 19), holiday: "Washington's Birthday"},
+		{name: "good friday", date: date(2024, time.March, 29), holiday: "Good Friday"},
+		{name: "good friday 2025", date: date(2025, time.April, 18), holiday: "Good Friday"},
+		{name: "memorial day", date: date(2024, time.May, 27), holiday: "Memorial Day"},
+		{name: "juneteenth", date: date(2024, time.June, 19), holiday: "Juneteenth"},
+		{name: "juneteenth observed monday", date: date(2022, time.June, 20), holiday: "Juneteenth"},
+		{name: "before juneteenth", date: date(2021, time.June, 18), tradingDay: true},
+		{name: "july 3 early close", date: date(2025, time.July, 3), tradingDay: true, holiday: "Independence Day", earlyClose: true},
+		{name: "independence day observed friday", date: date(2026, time.July, 3), holiday: "Independence Day"},
+		{name: "independence day", date: date(2024, time.July, 4), holiday: "Independence Day"},
+		{name: "labor day", date: date(2024, time.September, 2), holiday: "Labor Day"},
+		{name: "thanksgiving", date: date(2024, time.November, 28), holiday: "Thanksgiving Day"},
+		{name: "day after thanksgiving", date: date(2024, time.November, 29), tradingDay: true, holiday: "Thanksgiving Day", earlyClose: true},
+		{name: "christmas eve", date: date(2025, time.December, 24), tradingDay: true, holiday: "Christmas Day", earlyClose: true},
+		{name: "christmas", date: date(2024, time.December, 25), holiday: "Christmas Day"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day := GetDay(tt.date.Add(12 * time.Hour))
+			if !day.Date.Equal(tt.date) {
+				t.Errorf("GetDay(%v).Date = %v, want %v", tt.date, day.Date, tt.date)
+			}
+			if got := day.IsTradingDay(); got != tt.tradingDay {
+				t.Errorf("GetDay(%v).IsTradingDay() = %v, want %v", tt.date, got, tt.tradingDay)
+			}
+			if day.Holiday != tt.holiday {
+				t.Errorf("GetDay(%v).Holiday = %q, want %q", tt.date, day.Holiday, tt.holiday)
+			}
+			if day.EarlyClose != tt.earlyClose {
+				t.Errorf("GetDay(%v).EarlyClose = %v, want %v", tt.date, day.EarlyClose, tt.earlyClose)
+			}
+		})
+	}
+}
+
+func TestGetSession(t *testing.T) {
+	tests := []struct {
+		name string
+		t    time.Time
+		want Session
+	}{
+		{name: "overnight", t: at(2024, time.March, 11, 3, 59), want: Closed},
+		{name: "pre-market open", t: at(2024, time.March, 11, 4, 0), want: PreMarket},
+		{name: "before open", t: at(2024, time.March, 11, 9, 29), want: PreMarket},
+		{name: "open", t: at(2024, time.March, 11, 9, 30), want: Regular},
+		{name: "before close", t: at(2024, time.March, 11, 15, 59), want: Regular},
+		{name: "close", t: at(2024, time.March, 11, 16, 0), want: AfterHours},
+		{name: "after hours close", t: at(2024, time.March, 11, 20, 0), want: Closed},
+		{name: "weekend", t: at(2024, time.March, 9, 12, 0), want: Closed},
+		{name: "holiday", t: at(2024, time.December, 25, 12, 0), want: Closed},
+		{name: "early close", t: at(2024, time.November, 29, 13, 0), want: AfterHours},
+		{name: "early after hours close", t: at(2024, time.November, 29, 17, 0), want: Closed},
+		{name: "open in UTC", t: time.Date(2024, time.March, 11, 13, 30, 0, 0, time.UTC), want: Regular},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetSession(tt.t); got != tt.want {
+				t.Errorf("GetSession(%v) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		t         time.Time
+		nextOpen  time.Time
+		nextClose time.Time
+		describe  string
+	}{
+		{
+			name:      "friday after hours",
+			t:         at(2024, time.March, 8, 17, 0),
+			nextOpen:  at(2024, time.March, 11, 9, 30),
+			nextClose: at(2024, time.March, 11, 16, 0),
+			describe:  "After hours",
+		},
+		{
+			name:      "open",
+			t:         at(2024, time.March, 11, 10, 0),
+			nextOpen:  at(2024, time.March, 12, 9, 30),
+			nextClose: at(2024, time.March, 11, 16, 0),
+			describe:  "Market open",
+		},
+		{
+			name:      "holiday",
+			t:         at(2024, time.December, 25, 10, 0),
+			nextOpen:  at(2024, time.December, 26, 9, 30),
+			nextClose: at(2024, time.December, 26, 16, 0),
+			describe:  "Market closed for Christmas Day",
+		},
+		{
+			name:      "early close",
+			t:         at(2024, time.November, 29, 12, 0),
+			nextOpen:  at(2024, time.December, 2, 9, 30),
+			nextClose: at(2024, time.November, 29, 13, 0),
+			describe:  "Market open",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := GetStatus(tt.t)
+			if !status.NextOpen.Equal(tt.nextOpen) {
+				t.Errorf("GetStatus(%v).NextOpen = %v, want %v", tt.t, status.NextOpen, tt.nextOpen)
+			}
+			if !status.NextClose.Equal(tt.nextClose) {
+				t.Errorf("GetStatus(%v).NextClose = %v, want %v", tt.t, status.NextClose, tt.nextClose)
+			}
+			if got := status.Describe(); got != tt.describe {
+				t.Errorf("GetStatus(%v).Describe() = %q, want %q", tt.t, got, tt.describe)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package messagelib
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const comparisonChartFileName = "comparison.png"
+
+// ComparisonValue passes the performance of one asset in a comparison chart.
+type ComparisonValue struct {
+	Ticker string
+	// Legend identifies the asset's line on the chart, e.g. a colored square emoji.
+	Legend string
+	// Return is the percent change over the compared timeframe.
+	Return float64
+}
+
+// CreateComparisonMessage creates a message with a comparison chart and a legend of each asset's return over the timeframe.
+func CreateComparisonMessage(values []*ComparisonValue, timeframe string, chart []byte) *discordgo.MessageSend {
+	return createComparisonMessageWithPrefix(values, timeframe, chart, getTestServerID())
+}
+
+func createComparisonMessageWithPrefix(values []*ComparisonValue, timeframe string, chart []byte, prefix string) *discordgo.MessageSend {
+	var legend []string
+	for _, value := range values {
+		legend = append(legend, fmt.Sprintf("%s %s: %s", value.Legend, value.Ticker, formatPercent(value.Return)))
+	}
+	return &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       fmt.Sprintf("Comparison over %s", timeframe),
+			Description: strings.Join(legend, "\n"),
+			Image: &discordgo.MessageEmbedImage{
+				URL: "attachment://" + comparisonChartFileName,
+			},
+			Footer: &discordgo.MessageEmbedFooter{
+				Text: prefix,
+			},
+		}},
+		Files: []*discordgo.File{{
+			Name:        comparisonChartFileName,
+			ContentType: "image/png",
+			Reader:      bytes.NewReader(chart),
+		}},
+	}
+}
@@ -0,0 +1,148 @@
+package messagelib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// commandButtonPrefix and updateButtonPrefix start the custom ID of every CommandButton, so clicks
+	// can be told apart from other components, and those that update their message from those that don't.
+	commandButtonPrefix = "cmd:"
+	updateButtonPrefix  = "upd:"
+
+	// Discord allows 5 rows of 5 buttons on a message, and 80 characters in a button label.
+	maxButtonsPerRow = 5
+	maxButtonRows    = 5
+	maxButtonLabel   = 80
+	// Custom IDs are limited to 100 characters, so longer commands are stored and their key
+	// put in the custom ID instead, marked by storedCommandPrefix.
+	maxCustomID         = 100
+	storedCommandPrefix = "#"
+	storedCommandKeyLen = 16
+	// maxStoredCommands bounds the memory used by stored commands, forgetting the oldest first.
+	maxStoredCommands = 10000
+)
+
+var (
+	storedCommandsMu sync.Mutex
+	storedCommands   = make(map[string]string)
+	// storedCommandKeys are the keys of storedCommands, oldest first.
+	storedCommandKeys []string
+)
+
+// CommandButton is a message button that runs a bot command when clicked, as if the clicking user had sent it.
+type CommandButton struct {
+	Label string
+	// Command is the command and its arguments without the bot prefix, e.g. "quote AAPL".
+	Command string
+	// Update replaces the message the button is on with the command's reply, rather than sending a new message.
+	Update bool
+	// Primary highlights the button, e.g. to show which of a set of buttons is in effect.
+	Primary bool
+}
+
+// CreateCommandButtons lays out groups of buttons in rows of a message, starting each group on a new row.
+// Buttons beyond what Discord allows are dropped.
+func CreateCommandButtons(groups ...[]*CommandButton) []discordgo.MessageComponent {
+	var rows []discordgo.MessageComponent
+	n := 0
+	for _, buttons := range groups {
+		var row discordgo.ActionsRow
+		for _, button := range buttons {
+			prefix := commandButtonPrefix
+			if button.Update {
+				prefix = updateButtonPrefix
+			}
+			// Custom IDs must be unique within a message, but buttons may run the same command,
+			// e.g. refreshing a chart and picking its timeframe, so they are numbered.
+			customID := fmt.Sprintf("%s%d:%s", prefix, n, button.Command)
+			if len(customID) > maxCustomID {
+				customID = fmt.Sprintf("%s%d:%s%s", prefix, n, storedCommandPrefix, storeCommand(button.Command))
+			}
+			if len(row.Components) == maxButtonsPerRow {
+				rows = append(rows, row)
+				row = discordgo.ActionsRow{}
+			}
+			if len(rows) == maxButtonRows {
+				log.Printf("Dropped buttons beyond Discord's limit of %d rows, starting with %q", maxButtonRows, button.Label)
+				return rows
+			}
+			label := button.Label
+			if runes := []rune(label); len(runes) > maxButtonLabel {
+				label = string(runes[:maxButtonLabel-1]) + "…"
+			}
+			style := discordgo.SecondaryButton
+			if button.Primary {
+				style = discordgo.PrimaryButton
+			}
+			row.Components = append(row.Components, discordgo.Button{
+				Label:    label,
+				Style:    style,
+				CustomID: customID,
+			})
+			n++
+		}
+		if len(row.Components) > 0 && len(rows) < maxButtonRows {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// ParseCommandButton returns the command run by a clicked CommandButton's custom ID, and whether
+// its reply should update the button's message. It returns false if the custom ID isn't from a CommandButton.
+// The command is empty if it was too long for the custom ID and has since been forgotten, e.g. by a restart.
+func ParseCommandButton(customID string) (string, bool, bool) {
+	var update bool
+	switch {
+	case strings.HasPrefix(customID, commandButtonPrefix):
+		customID = strings.TrimPrefix(customID, commandButtonPrefix)
+	case strings.HasPrefix(customID, updateButtonPrefix):
+		customID, update = strings.TrimPrefix(customID, updateButtonPrefix), true
+	default:
+		return "", false, false
+	}
+	i := strings.Index(customID, ":")
+	if i < 0 {
+		return "", false, false
+	}
+	command := customID[i+1:]
+	if strings.HasPrefix(command, storedCommandPrefix) {
+		command = loadCommand(strings.TrimPrefix(command, storedCommandPrefix))
+	}
+	return command, update, true
+}
+
+// storeCommand stores a command too long for a custom ID, returning the key to load it by.
+// Keys are derived from the command, so refreshing the same command doesn't store it again.
+func storeCommand(command string) string {
+	sum := sha256.Sum256([]byte(command))
+	key := hex.EncodeToString(sum[:])[:storedCommandKeyLen]
+
+	storedCommandsMu.Lock()
+	defer storedCommandsMu.Unlock()
+	if _, ok := storedCommands[key]; ok {
+		return key
+	}
+	if len(storedCommandKeys) == maxStoredCommands {
+		delete(storedCommands, storedCommandKeys[0])
+		storedCommandKeys = storedCommandKeys[1:]
+	}
+	storedCommands[key] = command
+	storedCommandKeys = append(storedCommandKeys, key)
+	return key
+}
+
+// loadCommand returns the command stored under key, or "" if there is none.
+func loadCommand(key string) string {
+	storedCommandsMu.Lock()
+	defer storedCommandsMu.Unlock()
+	return storedCommands[key]
+}
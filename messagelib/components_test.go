@@ -0,0 +1,128 @@
+package messagelib
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestParseCommandButton(t *testing.T) {
+	tests := []struct {
+		customID    string
+		wantCommand string
+		wantUpdate  bool
+		wantOK      bool
+	}{
+		{customID: "cmd:0:quote AAPL", wantCommand: "quote AAPL", wantOK: true},
+		{customID: "upd:3:quote AAPL 1m", wantCommand: "quote AAPL 1m", wantUpdate: true, wantOK: true},
+		// Only the button index is split off, so commands may contain colons.
+		{customID: "cmd:1:alert add AAPL > 1:1", wantCommand: "alert add AAPL > 1:1", wantOK: true},
+		{customID: "cmd:0:#0000000000000000", wantCommand: "", wantOK: true},
+		{customID: "cmd:quote", wantOK: false},
+		{customID: "other:0:quote AAPL", wantOK: false},
+		{customID: "", wantOK: false},
+	}
+	for _, tt := range tests {
+		command, update, ok := ParseCommandButton(tt.customID)
+		if command != tt.wantCommand || update != tt.wantUpdate || ok != tt.wantOK {
+			t.Errorf("ParseCommandButton(%q) = %q, %v, %v, want %q, %v, %v", tt.customID, command, update, ok, tt.wantCommand, tt.wantUpdate, tt.wantOK)
+		}
+	}
+}
+
+// buttonRows returns the buttons of each row created by CreateCommandButtons.
+func buttonRows(t *testing.T, rows []discordgo.MessageComponent) [][]discordgo.Button {
+	t.Helper()
+	var ret [][]discordgo.Button
+	for _, row := range rows {
+		actionsRow, ok := row.(discordgo.ActionsRow)
+		if !ok {
+			t.Fatalf("row is a %T, want discordgo.ActionsRow", row)
+		}
+		var buttons []discordgo.Button
+		for _, component := range actionsRow.Components {
+			button, ok := component.(discordgo.Button)
+			if !ok {
+				t.Fatalf("component is a %T, want discordgo.Button", component)
+			}
+			buttons = append(buttons, button)
+		}
+		ret = append(ret, buttons)
+	}
+	return ret
+}
+
+func quoteButtons(n int) []*CommandButton {
+	var buttons []*CommandButton
+	for i := 0; i < n; i++ {
+		ticker := fmt.Sprintf("T%d", i)
+		buttons = append(buttons, &CommandButton{Label: ticker, Command: "quote " + ticker})
+	}
+	return buttons
+}
+
+func TestCreateCommandButtons(t *testing.T) {
+	tests := []struct {
+		name     string
+		groups   [][]*CommandButton
+		wantRows []int
+	}{
+		{name: "none", wantRows: nil},
+		{name: "one row", groups: [][]*CommandButton{quoteButtons(3)}, wantRows: []int{3}},
+		{name: "wrapped", groups: [][]*CommandButton{quoteButtons(7)}, wantRows: []int{5, 2}},
+		{name: "groups on new rows", groups: [][]*CommandButton{quoteButtons(1), quoteButtons(2)}, wantRows: []int{1, 2}},
+		{name: "empty group", groups: [][]*CommandButton{quoteButtons(1), nil, quoteButtons(1)}, wantRows: []int{1, 1}},
+		{name: "too many", groups: [][]*CommandButton{quoteButtons(30)}, wantRows: []int{5, 5, 5, 5, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := buttonRows(t, CreateCommandButtons(tt.groups...))
+			var got []int
+			for _, row := range rows {
+				got = append(got, len(row))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.wantRows) {
+				t.Errorf("CreateCommandButtons() rows = %v, want %v", got, tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestCreateCommandButtonsRoundTrip(t *testing.T) {
+	longCommand := "quote " + strings.Repeat("AAPL ", 30)
+	buttons := []*CommandButton{
+		{Label: "Refresh", Command: longCommand, Update: true},
+		{Label: "1D", Command: "quote AAPL 1d", Update: true, Primary: true},
+		{Label: "1D", Command: "quote AAPL 1d", Update: true},
+		{Label: strings.Repeat("x", 100), Command: "watch add AAPL"},
+	}
+	rows := buttonRows(t, CreateCommandButtons(buttons))
+	if len(rows) != 1 || len(rows[0]) != len(buttons) {
+		t.Fatalf("CreateCommandButtons() = %v, want one row of %d buttons", rows, len(buttons))
+	}
+
+	seen := make(map[string]bool)
+	for i, button := range rows[0] {
+		if len(button.CustomID) > maxCustomID {
+			t.Errorf("button %d custom ID is %d characters, more than %d", i, len(button.CustomID), maxCustomID)
+		}
+		if seen[button.CustomID] {
+			t.Errorf("button %d custom ID %q isn't unique", i, button.CustomID)
+		}
+		seen[button.CustomID] = true
+
+		command, update, ok := ParseCommandButton(button.CustomID)
+		if !ok || command != buttons[i].Command || update != buttons[i].Update {
+			t.Errorf("ParseCommandButton(%q) = %q, %v, %v, want %q, %v, true", button.CustomID, command, update, ok, buttons[i].Command, buttons[i].Update)
+		}
+	}
+
+	if got := []rune(rows[0][3].Label); len(got) != maxButtonLabel {
+		t.Errorf("long label is %d characters, want %d", len(got), maxButtonLabel)
+	}
+	if rows[0][1].Style != discordgo.PrimaryButton || rows[0][2].Style != discordgo.SecondaryButton {
+		t.Errorf("button styles = %v, %v, want primary, secondary", rows[0][1].Style, rows[0][2].Style)
+	}
+}
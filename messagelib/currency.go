@@ -0,0 +1,51 @@
+package messagelib
+
+const (
+	// defaultCurrency is the currency of TickerValues that don't set one.
+	defaultCurrency = "USD"
+	// defaultPrecision is the number of decimal places of TickerValues that don't set one.
+	defaultPrecision = 4
+)
+
+// currencySymbols are prefixed to prices in common currencies. Prices in other currencies are prefixed with their code.
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+	"CNY": "CN¥",
+	"INR": "₹",
+	"KRW": "₩",
+	"CAD": "CA$",
+	"AUD": "A$",
+	"NZD": "NZ$",
+	"HKD": "HK$",
+	"SGD": "S$",
+	"BTC": "₿",
+	"ETH": "Ξ",
+}
+
+// FormatPriceIn formats a price in a currency for display in a message, e.g. "€12.5" or "CHF 12.5".
+// An empty currency is USD.
+func FormatPriceIn(value float32, currency string) string {
+	return currencySymbol(currency) + formatFloat(value, defaultPrecision)
+}
+
+// formatTickerPrice formats a price of a ticker in its currency and precision.
+func formatTickerPrice(tickerValue *TickerValue, value float32) string {
+	precision := tickerValue.Precision
+	if precision == 0 {
+		precision = defaultPrecision
+	}
+	return currencySymbol(tickerValue.Currency) + formatFloat(value, precision)
+}
+
+func currencySymbol(currency string) string {
+	if currency == "" {
+		currency = defaultCurrency
+	}
+	if symbol, ok := currencySymbols[currency]; ok {
+		return symbol
+	}
+	return currency + " "
+}
@@ -0,0 +1,115 @@
+package messagelib
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxCategories is how many of a crypto asset's categories are shown. CoinGecko lists dozens for some coins.
+const maxCategories = 4
+
+// TickerDetail passes everything known about a ticker beyond its latest price.
+// Zero values are unknown and left out of embeds.
+type TickerDetail struct {
+	TickerValue
+
+	Open          float32
+	High          float32
+	Low           float32
+	PreviousClose float32
+	Volume        float64
+	MarketCap     float64
+	PERatio       float64
+	YearHigh      float64
+	YearLow       float64
+	Industry      string
+	Website       string
+	// Categories are what kind of asset a crypto asset is, e.g. "Smart Contract Platform".
+	Categories []string
+}
+
+// CreateDetailEmbed creates an embed with the day's trading, valuation and 52 week range of a ticker.
+func CreateDetailEmbed(detail *TickerDetail) *discordgo.MessageEmbed {
+	return createDetailEmbedWithPrefix(detail, getTestServerID())
+}
+
+func createDetailEmbedWithPrefix(detail *TickerDetail, prefix string) *discordgo.MessageEmbed {
+	url := detail.Website
+	if url == "" {
+		url = fmt.Sprintf("https://www.google.com/search?q=%s", detail.Ticker)
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       detail.Ticker,
+		URL:         url,
+		Description: createMessageEmbedField(&detail.TickerValue).Value,
+		Footer:      createFooter(prefix, &detail.TickerValue),
+	}
+	if detail.Logo != "" {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: detail.Logo}
+	}
+
+	addField := func(name string, value string) {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   name,
+			Value:  value,
+			Inline: true,
+		})
+	}
+	if detail.Open != 0 {
+		addField("Open", FormatPrice(detail.Open))
+	}
+	if detail.PreviousClose != 0 {
+		addField("Previous Close", FormatPrice(detail.PreviousClose))
+	}
+	if detail.Low != 0 && detail.High != 0 {
+		addField("Day Range", fmt.Sprintf("%s - %s", FormatPrice(detail.Low), FormatPrice(detail.High)))
+	}
+	if detail.Volume != 0 {
+		addField("Volume", formatLargeNumber(detail.Volume))
+	}
+	if detail.MarketCap != 0 {
+		addField("Market Cap", "$"+formatLargeNumber(detail.MarketCap))
+	}
+	if detail.PERatio != 0 {
+		addField("P/E", fmt.Sprintf("%.2f", detail.PERatio))
+	}
+	if detail.YearLow != 0 && detail.YearHigh != 0 {
+		addField("52 Week Range", fmt.Sprintf("%s - %s", FormatPrice(float32(detail.YearLow)), FormatPrice(float32(detail.YearHigh))))
+	}
+	if detail.Industry != "" {
+		addField("Industry", detail.Industry)
+	}
+	if len(detail.Categories) > 0 {
+		addField("Categories", strings.Join(firstN(detail.Categories, maxCategories), ", "))
+	}
+	return embed
+}
+
+// formatLargeNumber abbreviates numbers with a K, M, B or T suffix.
+func formatLargeNumber(value float64) string {
+	for _, unit := range []struct {
+		size   float64
+		suffix string
+	}{
+		{1e12, "T"},
+		{1e9, "B"},
+		{1e6, "M"},
+		{1e3, "K"},
+	} {
+		if math.Abs(value) >= unit.size {
+			return fmt.Sprintf("%.2f%s", value/unit.size, unit.suffix)
+		}
+	}
+	return fmt.Sprintf("%.0f", value)
+}
+
+// firstN returns up to the first n elements of s.
+func firstN(s []string, n int) []string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,123 @@
+package messagelib
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord rejects embed descriptions longer than 4096 characters.
+const maxDescriptionLength = 4096
+
+// EarningsEvent passes a scheduled or reported earnings release. Zero estimates are unknown.
+type EarningsEvent struct {
+	Ticker string
+	// Date is midnight at the start of the release date in the market's time zone.
+	Date time.Time
+	// Hour is "bmo" before market open, "amc" after market close, or "dmh" during market hours.
+	Hour            string
+	Year            int
+	Quarter         int
+	EPSEstimate     float64
+	EPSActual       float64
+	RevenueEstimate float64
+}
+
+// EarningsSurprise passes the reported and estimated EPS of a past quarter.
+type EarningsSurprise struct {
+	// Period is the end of the reported quarter, e.g. "2026-06-30".
+	Period   string
+	Actual   float64
+	Estimate float64
+}
+
+// Percent returns how far the actual EPS beat or missed the estimate, as a percent of the estimate.
+func (s *EarningsSurprise) Percent() float64 {
+	if s.Estimate == 0 {
+		return 0
+	}
+	return (s.Actual - s.Estimate) / math.Abs(s.Estimate) * 100
+}
+
+// CreateEarningsEmbed creates an embed with a ticker's next earnings release and recent surprises.
+// next may be nil if no release is scheduled.
+func CreateEarningsEmbed(ticker string, next *EarningsEvent, surprises []*EarningsSurprise) *discordgo.MessageEmbed {
+	return createEarningsEmbedWithPrefix(ticker, next, surprises, getTestServerID())
+}
+
+func createEarningsEmbedWithPrefix(ticker string, next *EarningsEvent, surprises []*EarningsSurprise, prefix string) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("%s Earnings", ticker),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: prefix,
+		},
+	}
+	if next == nil {
+		embed.Description = "No upcoming earnings scheduled."
+	} else {
+		lines := []string{fmt.Sprintf("Next report: %s", formatEarningsDate(next))}
+		if next.Quarter != 0 {
+			lines = append(lines, fmt.Sprintf("Quarter: Q%d %d", next.Quarter, next.Year))
+		}
+		if next.EPSEstimate != 0 {
+			lines = append(lines, fmt.Sprintf("EPS estimate: %s", formatMoney(next.EPSEstimate)))
+		}
+		if next.RevenueEstimate != 0 {
+			lines = append(lines, fmt.Sprintf("Revenue estimate: $%s", formatLargeNumber(next.RevenueEstimate)))
+		}
+		embed.Description = strings.Join(lines, "\n")
+	}
+	for _, surprise := range surprises {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   fmt.Sprintf("Quarter ending %s", surprise.Period),
+			Value:  fmt.Sprintf("EPS %s vs %s estimate (%s)", formatMoney(surprise.Actual), formatMoney(surprise.Estimate), formatPercent(surprise.Percent())),
+			Inline: false,
+		})
+	}
+	return embed
+}
+
+// CreateEarningsCalendarEmbed creates an embed listing earnings releases, one per line.
+func CreateEarningsCalendarEmbed(title string, events []*EarningsEvent) *discordgo.MessageEmbed {
+	return createEarningsCalendarEmbedWithPrefix(title, events, getTestServerID())
+}
+
+func createEarningsCalendarEmbedWithPrefix(title string, events []*EarningsEvent, prefix string) *discordgo.MessageEmbed {
+	var b strings.Builder
+	for _, event := range events {
+		line := fmt.Sprintf("**%s** %s", event.Ticker, formatEarningsDate(event))
+		if event.EPSEstimate != 0 {
+			line = fmt.Sprintf("%s, EPS est. %s", line, formatMoney(event.EPSEstimate))
+		}
+		if b.Len()+len(line)+1 > maxDescriptionLength {
+			break
+		}
+		b.WriteString(line + "\n")
+	}
+	if len(events) == 0 {
+		b.WriteString("No earnings scheduled.")
+	}
+	return &discordgo.MessageEmbed{
+		Title:       title,
+		Description: b.String(),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: prefix,
+		},
+	}
+}
+
+func formatEarningsDate(event *EarningsEvent) string {
+	date := event.Date.Format("Mon Jan 2")
+	switch event.Hour {
+	case "bmo":
+		return date + " before open"
+	case "amc":
+		return date + " after close"
+	case "dmh":
+		return date + " during market hours"
+	}
+	return date
+}
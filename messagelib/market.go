@@ -0,0 +1,77 @@
+package messagelib
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// MarketStatus passes the state and schedule of a stock market.
+type MarketStatus struct {
+	Name    string
+	Session string
+	// Sessions are the names and hours of each session of the current or next trading day.
+	Sessions  []*MarketSession
+	NextOpen  time.Time
+	NextClose time.Time
+	Holidays  []*MarketHoliday
+}
+
+// MarketSession passes the hours of a single trading session.
+type MarketSession struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+}
+
+// MarketHoliday passes a day the market is closed or closes early.
+type MarketHoliday struct {
+	Name       string
+	Date       time.Time
+	EarlyClose bool
+}
+
+// CreateMarketEmbed creates an embed describing a market's session, hours and upcoming holidays.
+func CreateMarketEmbed(status *MarketStatus) *discordgo.MessageEmbed {
+	return createMarketEmbedWithPrefix(status, getTestServerID())
+}
+
+func createMarketEmbedWithPrefix(status *MarketStatus, prefix string) *discordgo.MessageEmbed {
+	var fields []*discordgo.MessageEmbedField
+	for _, session := range status.Sessions {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   session.Name,
+			Value:  fmt.Sprintf("<t:%d:t> - <t:%d:t>", session.Start.Unix(), session.End.Unix()),
+			Inline: true,
+		})
+	}
+	if len(status.Holidays) > 0 {
+		var holidays []string
+		for _, holiday := range status.Holidays {
+			line := fmt.Sprintf("%s: %s", holiday.Date.Format("Mon Jan 2"), holiday.Name)
+			if holiday.EarlyClose {
+				line += " (early close)"
+			}
+			holidays = append(holidays, line)
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "Upcoming holidays",
+			Value:  strings.Join(holidays, "\n"),
+			Inline: false,
+		})
+	}
+	return &discordgo.MessageEmbed{
+		Title: status.Name,
+		Description: strings.Join([]string{
+			status.Session,
+			fmt.Sprintf("Next open: <t:%d:F> (<t:%d:R>)", status.NextOpen.Unix(), status.NextOpen.Unix()),
+			fmt.Sprintf("Next close: <t:%d:F> (<t:%d:R>)", status.NextClose.Unix(), status.NextClose.Unix()),
+		}, "\n"),
+		Fields: fields,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: prefix,
+		},
+	}
+}
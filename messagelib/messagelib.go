@@ -1,17 +1,25 @@
 package messagelib
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"math"
+	"net/http"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/JoeParrinello/brokerbot/firestorelib"
 	"github.com/bwmarrin/discordgo"
 )
 
+// Discord rejects messages with more than 10 embeds.
+const maxEmbeds = 10
+
 var (
 	test          bool   = false
 	messagePrefix string = "TEST"
@@ -19,10 +27,35 @@ var (
 
 // TickerValue passes values of fetched content.
 type TickerValue struct {
-	Ticker   string
-	Value    float32
-	Change   float32
-	ChartUrl string
+	Ticker string
+	Value  float32
+	Change float32
+	// Chart is a PNG chart of the ticker, or nil if none was rendered.
+	Chart []byte
+	// Session describes the state of the ticker's market, e.g. "After hours", or "" if it always trades.
+	Session string
+	// LastTrade is when the ticker last traded, or zero if unknown.
+	LastTrade time.Time
+	// ExtendedHours is the latest pre or post market price, or nil if there is none.
+	ExtendedHours *ExtendedHoursValue
+	// Currency is the ISO code of the currency Value is in, or "" for USD.
+	Currency string
+	// Precision is the number of decimal places prices are shown with, or 0 for the default.
+	Precision int
+	// Logo is the URL of the ticker's logo, shown as the thumbnail of embeds of just this ticker, or "" if unknown.
+	Logo string
+	// Source is the name of the data source the quote came from, e.g. "Kraken", for tickers
+	// that can be quoted from more than one. It is credited in the embed footer.
+	Source string
+}
+
+// ExtendedHoursValue passes a price from outside the regular trading session.
+type ExtendedHoursValue struct {
+	// Session is the name of the session the price is from, e.g. "Pre-market".
+	Session string
+	Value   float32
+	// Change is the percent change from the regular session price.
+	Change float32
 }
 
 // EnterTestModeWithPrefix enables extra log prefixes to identify a test server.
@@ -56,6 +89,157 @@ func SendMessageEmbed(s *discordgo.Session, channelID string, msg *discordgo.Mes
 	return message
 }
 
+// Replier sends responses back to wherever a request came from.
+type Replier interface {
+	Reply(data *discordgo.MessageSend) (*discordgo.Message, error)
+}
+
+// ChannelReplier replies by posting new messages to a Discord channel.
+type ChannelReplier struct {
+	Session   *discordgo.Session
+	ChannelID string
+}
+
+// Reply implements Replier.
+func (r *ChannelReplier) Reply(data *discordgo.MessageSend) (*discordgo.Message, error) {
+	return r.Session.ChannelMessageSendComplex(r.ChannelID, data)
+}
+
+// InteractionReplier replies to a deferred Discord interaction. The first reply
+// fills in the deferred response and any later replies are sent as followups.
+type InteractionReplier struct {
+	Session     *discordgo.Session
+	Interaction *discordgo.Interaction
+
+	mu        sync.Mutex
+	responded bool
+}
+
+// Reply implements Replier.
+func (r *InteractionReplier) Reply(data *discordgo.MessageSend) (*discordgo.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.responded {
+		message, err := r.Session.InteractionResponseEdit(r.Interaction, &discordgo.WebhookEdit{
+			Content:    data.Content,
+			Embeds:     data.Embeds,
+			Files:      data.Files,
+			Components: data.Components,
+		})
+		if err == nil {
+			r.responded = true
+		}
+		return message, err
+	}
+	return r.Session.FollowupMessageCreate(r.Interaction, true, &discordgo.WebhookParams{
+		Content:    data.Content,
+		Embeds:     data.Embeds,
+		Files:      data.Files,
+		Components: data.Components,
+	})
+}
+
+// MessageUpdater replies to a deferred message component interaction by editing the message the
+// component is on. The first reply replaces the message and any later replies are sent as followups.
+type MessageUpdater struct {
+	Session     *discordgo.Session
+	Interaction *discordgo.Interaction
+
+	mu        sync.Mutex
+	responded bool
+}
+
+// messageUpdate is the body of an edit to an interaction's message. Replies without embeds, e.g. errors,
+// only replace the content, leaving the embeds and buttons they'd be about.
+type messageUpdate struct {
+	Content    string                        `json:"content"`
+	Embeds     []*discordgo.MessageEmbed     `json:"embeds,omitempty"`
+	Components *[]discordgo.MessageComponent `json:"components,omitempty"`
+	// Attachments lists the existing attachments to keep, which is none when the embeds are
+	// replaced, so an old chart isn't left beside its replacement.
+	Attachments *[]*discordgo.MessageAttachment `json:"attachments,omitempty"`
+}
+
+// Reply implements Replier.
+func (r *MessageUpdater) Reply(data *discordgo.MessageSend) (*discordgo.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.responded {
+		return r.Session.FollowupMessageCreate(r.Interaction, true, &discordgo.WebhookParams{
+			Content:    data.Content,
+			Embeds:     data.Embeds,
+			Files:      data.Files,
+			Components: data.Components,
+		})
+	}
+
+	update := &messageUpdate{
+		Content: data.Content,
+		Embeds:  data.Embeds,
+	}
+	if len(data.Embeds) > 0 {
+		components := append([]discordgo.MessageComponent{}, data.Components...)
+		update.Components = &components
+		update.Attachments = &[]*discordgo.MessageAttachment{}
+	}
+	// discordgo's WebhookEdit can't clear attachments, so the edit is sent directly.
+	uri := discordgo.EndpointWebhookMessage(r.Interaction.AppID, r.Interaction.Token, "@original")
+	var response []byte
+	var err error
+	if len(data.Files) > 0 {
+		var contentType string
+		var body []byte
+		contentType, body, err = discordgo.MultipartBodyWithJSON(update, data.Files)
+		if err != nil {
+			return nil, err
+		}
+		response, err = r.Session.RequestWithLockedBucket(http.MethodPatch, uri, contentType, body, r.Session.Ratelimiter.LockBucket(uri), 0)
+	} else {
+		response, err = r.Session.RequestWithBucketID(http.MethodPatch, uri, update, uri)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.responded = true
+
+	var message discordgo.Message
+	if err := json.Unmarshal(response, &message); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal updated message: %v", err)
+	}
+	return &message, nil
+}
+
+// ReplyMessage sends a plaintext reply through a Replier.
+func ReplyMessage(r Replier, msg string) *discordgo.Message {
+	msg = fmt.Sprintf("%s%s", getMessagePrefix(), msg)
+	message, err := r.Reply(&discordgo.MessageSend{Content: msg})
+	if err != nil {
+		log.Printf("failed to send message %q to discord: %v", msg, err)
+	}
+	return message
+}
+
+// ReplyMessageEmbed sends a rich "embed" reply through a Replier.
+func ReplyMessageEmbed(r Replier, msg *discordgo.MessageEmbed) *discordgo.Message {
+	message, err := r.Reply(&discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{msg}})
+	if err != nil {
+		log.Printf("failed to send message %+v to discord: %v", msg, err)
+	}
+	return message
+}
+
+// ReplyMessageComplex sends a message with any combination of content, embeds and files through a Replier.
+func ReplyMessageComplex(r Replier, msg *discordgo.MessageSend) *discordgo.Message {
+	if msg.Content != "" {
+		msg.Content = fmt.Sprintf("%s%s", getMessagePrefix(), msg.Content)
+	}
+	message, err := r.Reply(msg)
+	if err != nil {
+		log.Printf("failed to send message %+v to discord: %v", msg, err)
+	}
+	return message
+}
+
 // CreateMessageEmbed creates a rich Discord "embed" message
 func CreateMessageEmbed(tickerValue *TickerValue) *discordgo.MessageEmbed {
 	return createMessageEmbedWithPrefix(tickerValue, getTestServerID())
@@ -66,7 +250,7 @@ func createMessageEmbedWithPrefix(tickerValue *TickerValue, prefix string) *disc
 		return nil
 	}
 
-	mesg := fmt.Sprintf("Latest Quote: $%s", formatFloat(tickerValue.Value, 4))
+	mesg := fmt.Sprintf("Latest Quote: %s", formatTickerPrice(tickerValue, tickerValue.Value))
 	if !math.IsNaN(float64(tickerValue.Change)) && tickerValue.Change != 0 {
 		mesg = fmt.Sprintf("%s (%s%%)", mesg, formatFloat(tickerValue.Change, 4))
 	}
@@ -74,9 +258,8 @@ func createMessageEmbedWithPrefix(tickerValue *TickerValue, prefix string) *disc
 		Title:       tickerValue.Ticker,
 		URL:         fmt.Sprintf("https://www.google.com/search?q=%s", tickerValue.Ticker),
 		Description: mesg,
-		Footer: &discordgo.MessageEmbedFooter{
-			Text: prefix,
-		},
+		Footer:      createFooter(prefix, tickerValue),
+		Thumbnail:   createThumbnail(tickerValue),
 	}
 }
 
@@ -90,23 +273,46 @@ func createMultiMessageEmbedWithPrefix(tickers []*TickerValue, prefix string) *d
 	for i, ticker := range tickers {
 		messageFields[i] = createMessageEmbedField(ticker)
 	}
-	if len(tickers) == 1 && tickers[0].ChartUrl != "" {
-		return &discordgo.MessageEmbed{
-			Fields: messageFields,
-			Footer: &discordgo.MessageEmbedFooter{
-				Text: prefix,
-			},
+	return &discordgo.MessageEmbed{
+		Fields: messageFields,
+		Footer: createFooter(prefix, tickers...),
+	}
+}
+
+// CreateQuoteMessage returns a message quoting the tickers. Tickers with a chart get their
+// own embed with the chart attached, and the rest share an embed like CreateMultiMessageEmbed.
+func CreateQuoteMessage(tickers []*TickerValue) *discordgo.MessageSend {
+	return createQuoteMessageWithPrefix(tickers, getTestServerID())
+}
+
+func createQuoteMessageWithPrefix(tickers []*TickerValue, prefix string) *discordgo.MessageSend {
+	msg := &discordgo.MessageSend{}
+	var unchartedTickers []*TickerValue
+	for _, ticker := range tickers {
+		// Leave room for the embed of uncharted tickers.
+		if ticker.Chart == nil || len(msg.Embeds) == maxEmbeds-1 {
+			unchartedTickers = append(unchartedTickers, ticker)
+			continue
+		}
+		fileName := fmt.Sprintf("chart%d.png", len(msg.Files))
+		msg.Files = append(msg.Files, &discordgo.File{
+			Name:        fileName,
+			ContentType: "image/png",
+			Reader:      bytes.NewReader(ticker.Chart),
+		})
+		msg.Embeds = append(msg.Embeds, &discordgo.MessageEmbed{
+			Fields:    []*discordgo.MessageEmbedField{createMessageEmbedField(ticker)},
+			Footer:    createFooter(prefix, ticker),
+			Thumbnail: createThumbnail(ticker),
 			Image: &discordgo.MessageEmbedImage{
-				URL: tickers[0].ChartUrl,
+				URL: "attachment://" + fileName,
 			},
-		}
+		})
 	}
-	return &discordgo.MessageEmbed{
-		Fields: messageFields,
-		Footer: &discordgo.MessageEmbedFooter{
-			Text: prefix,
-		},
+	if len(unchartedTickers) > 0 {
+		msg.Embeds = append(msg.Embeds, createMultiMessageEmbedWithPrefix(unchartedTickers, prefix))
 	}
+	return msg
 }
 
 func createMessageEmbedField(tickerValue *TickerValue) *discordgo.MessageEmbedField {
@@ -118,10 +324,16 @@ func createMessageEmbedField(tickerValue *TickerValue) *discordgo.MessageEmbedFi
 		}
 	}
 
-	mesg := fmt.Sprintf("$%s", formatFloat(tickerValue.Value, 4))
+	mesg := formatTickerPrice(tickerValue, tickerValue.Value)
 	if !math.IsNaN(float64(tickerValue.Change)) && tickerValue.Change != 0 {
 		mesg = fmt.Sprintf("%s (%s%%)", mesg, formatFloat(tickerValue.Change, 4))
 	}
+	if extended := tickerValue.ExtendedHours; extended != nil {
+		mesg = fmt.Sprintf("%s\n%s: %s (%s%%)", mesg, extended.Session, formatTickerPrice(tickerValue, extended.Value), formatFloat(extended.Change, 4))
+	}
+	if status := formatSessionStatus(tickerValue); status != "" {
+		mesg = fmt.Sprintf("%s\n%s", mesg, status)
+	}
 	return &discordgo.MessageEmbedField{
 		Name:   tickerValue.Ticker,
 		Value:  mesg,
@@ -129,6 +341,52 @@ func createMessageEmbedField(tickerValue *TickerValue) *discordgo.MessageEmbedFi
 	}
 }
 
+// formatSessionStatus returns the market session and last trade time of a ticker, if known.
+func formatSessionStatus(tickerValue *TickerValue) string {
+	var parts []string
+	if tickerValue.Session != "" {
+		parts = append(parts, tickerValue.Session)
+	}
+	if !tickerValue.LastTrade.IsZero() {
+		// Discord renders timestamps in each reader's own time zone.
+		parts = append(parts, fmt.Sprintf("Last trade <t:%d:f>", tickerValue.LastTrade.Unix()))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// createThumbnail returns an embed thumbnail of the ticker's logo, or nil if it has none.
+func createThumbnail(tickerValue *TickerValue) *discordgo.MessageEmbedThumbnail {
+	if tickerValue.Logo == "" {
+		return nil
+	}
+	return &discordgo.MessageEmbedThumbnail{URL: tickerValue.Logo}
+}
+
+// createFooter returns an embed footer with the prefix, crediting the sources the tickers were quoted from.
+func createFooter(prefix string, tickers ...*TickerValue) *discordgo.MessageEmbedFooter {
+	var sources []string
+	seen := make(map[string]bool)
+	for _, ticker := range tickers {
+		if ticker.Source != "" && !seen[ticker.Source] {
+			seen[ticker.Source] = true
+			sources = append(sources, ticker.Source)
+		}
+	}
+	if len(sources) == 0 {
+		return &discordgo.MessageEmbedFooter{Text: prefix}
+	}
+	credit := "Data from " + strings.Join(sources, ", ")
+	if prefix == "" {
+		return &discordgo.MessageEmbedFooter{Text: credit}
+	}
+	return &discordgo.MessageEmbedFooter{Text: prefix + " · " + credit}
+}
+
+// FormatPrice formats a price for display in a message.
+func FormatPrice(value float32) string {
+	return FormatPriceIn(value, defaultCurrency)
+}
+
 func formatFloat(num float32, prc int) string {
 	str := fmt.Sprintf("%."+strconv.Itoa(prc)+"f", num)
 	return strings.TrimRight(strings.TrimRight(str, "0"), ".")
@@ -167,9 +425,10 @@ func CanonicalizeMessage(s []string) (ret []string) {
 }
 
 // ExpandAliases takes a string that contains an alias of format "?<alias>" and replaces the alias with the valid ticker string.
-func ExpandAliases(ctx context.Context, s []string) ([]string, error) {
+// Aliases defined in the guild take precedence over global aliases.
+func ExpandAliases(ctx context.Context, guildID string, s []string) ([]string, error) {
 	var ret []string
-	aliasMap, err := firestorelib.GetAliases(ctx)
+	aliasMap, err := firestorelib.GetAliases(ctx, guildID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch aliases: %v", err)
 	}
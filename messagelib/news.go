@@ -0,0 +1,58 @@
+package messagelib
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord rejects embed field names longer than 256 characters.
+const maxFieldNameLength = 256
+
+// NewsItem passes a single news headline.
+type NewsItem struct {
+	Headline string
+	Source   string
+	URL      string
+	Time     time.Time
+}
+
+// CreateNewsEmbed creates an embed listing headlines with their source, publish time and link.
+func CreateNewsEmbed(title string, items []*NewsItem) *discordgo.MessageEmbed {
+	return createNewsEmbedWithPrefix(title, items, getTestServerID())
+}
+
+func createNewsEmbedWithPrefix(title string, items []*NewsItem, prefix string) *discordgo.MessageEmbed {
+	var fields []*discordgo.MessageEmbedField
+	for _, item := range items {
+		if len(fields) == maxEmbedFields {
+			break
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   truncate(item.Headline, maxFieldNameLength),
+			Value:  fmt.Sprintf("[%s](%s) · <t:%d:R>", item.Source, item.URL, item.Time.Unix()),
+			Inline: false,
+		})
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:  title,
+		Fields: fields,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: prefix,
+		},
+	}
+	if len(fields) == 0 {
+		embed.Description = "No recent news."
+	}
+	return embed
+}
+
+// truncate shortens s to at most n runes, ending it with an ellipsis if anything was cut.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,131 @@
+package messagelib
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// optionQuoteFormat lays out the bid/ask, open interest and IV columns of one side of a strike ladder.
+const optionQuoteFormat = "%-13s %6s %4s"
+
+// OptionQuote passes one side of an option chain at a strike. IV is a percentage.
+type OptionQuote struct {
+	Bid          float64
+	Ask          float64
+	OpenInterest float64
+	IV           float64
+}
+
+// OptionStrike pairs the call and put at a strike. Either may be nil if it isn't listed.
+type OptionStrike struct {
+	Strike float64
+	Call   *OptionQuote
+	Put    *OptionQuote
+}
+
+// OptionChain passes the strikes of a stock's options expiring on one date, in strike order.
+type OptionChain struct {
+	Ticker  string
+	Price   float64
+	Expiry  time.Time
+	Strikes []*OptionStrike
+}
+
+// ExpiryVolatility passes the implied volatility and positioning of a stock's options expiring on one date.
+type ExpiryVolatility struct {
+	Expiry                   time.Time
+	IV                       float64
+	PutCallVolumeRatio       float64
+	PutCallOpenInterestRatio float64
+}
+
+// VolatilitySummary passes the implied volatility of a stock's options. IVs are percentages.
+type VolatilitySummary struct {
+	Ticker string
+	Price  float64
+	// Expiry is the nearest expiration, which ATMIV and ExpectedMove are for.
+	Expiry       time.Time
+	ATMIV        float64
+	ExpectedMove float64
+	Expiries     []*ExpiryVolatility
+}
+
+// CreateOptionChainEmbed creates an embed with a strike ladder of calls and puts around the stock's price.
+// The ladder is a code block so its columns line up, since embed fields can't be laid out as a table.
+func CreateOptionChainEmbed(chain *OptionChain) *discordgo.MessageEmbed {
+	return createOptionChainEmbedWithPrefix(chain, getTestServerID())
+}
+
+func createOptionChainEmbedWithPrefix(chain *OptionChain, prefix string) *discordgo.MessageEmbed {
+	row := func(call string, strike string, put string) string {
+		return strings.TrimRight(fmt.Sprintf("%-25s │ %8s │ %s", call, strike, put), " ") + "\n"
+	}
+	header := fmt.Sprintf(optionQuoteFormat, "Bid/Ask", "OI", "IV")
+	var b strings.Builder
+	b.WriteString("```\n")
+	b.WriteString(row(header, "Strike", header))
+	pricePrinted := false
+	for _, strike := range chain.Strikes {
+		if !pricePrinted && strike.Strike >= chain.Price {
+			b.WriteString(row("", fmt.Sprintf("▸%.2f", chain.Price), ""))
+			pricePrinted = true
+		}
+		b.WriteString(row(formatOptionQuote(strike.Call), fmt.Sprintf("%.2f", strike.Strike), formatOptionQuote(strike.Put)))
+	}
+	if !pricePrinted {
+		b.WriteString(row("", fmt.Sprintf("▸%.2f", chain.Price), ""))
+	}
+	b.WriteString("```")
+
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%s Options · %s", chain.Ticker, chain.Expiry.Format("Mon Jan 2, 2006")),
+		Description: fmt.Sprintf("Calls on the left, puts on the right. Last price %s\n%s", formatMoney(chain.Price), b.String()),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: prefix,
+		},
+	}
+}
+
+// formatOptionQuote formats one side of a strike to fit a ladder column, or "-" if it isn't listed.
+func formatOptionQuote(quote *OptionQuote) string {
+	if quote == nil {
+		return "-"
+	}
+	return fmt.Sprintf(optionQuoteFormat, fmt.Sprintf("%.2f/%.2f", quote.Bid, quote.Ask), formatLargeNumber(quote.OpenInterest), fmt.Sprintf("%.0f%%", quote.IV))
+}
+
+// CreateVolatilityEmbed creates an embed summarizing the implied volatility of a stock's options.
+func CreateVolatilityEmbed(summary *VolatilitySummary) *discordgo.MessageEmbed {
+	return createVolatilityEmbedWithPrefix(summary, getTestServerID())
+}
+
+func createVolatilityEmbedWithPrefix(summary *VolatilitySummary, prefix string) *discordgo.MessageEmbed {
+	expiry := summary.Expiry.Format("Jan 2")
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("%s Implied Volatility", summary.Ticker),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Last Price", Value: formatMoney(summary.Price), Inline: true},
+			{Name: fmt.Sprintf("ATM IV (%s)", expiry), Value: fmt.Sprintf("%.1f%%", summary.ATMIV), Inline: true},
+			{Name: fmt.Sprintf("Expected Move (%s)", expiry), Value: fmt.Sprintf("±%s (%.1f%%)", formatMoney(summary.ExpectedMove), summary.ExpectedMove/summary.Price*100), Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: prefix,
+		},
+	}
+
+	var b strings.Builder
+	b.WriteString("```\n")
+	b.WriteString(fmt.Sprintf("%-12s %6s %8s %8s\n", "Expiration", "IV", "P/C Vol", "P/C OI"))
+	for _, expiration := range summary.Expiries {
+		b.WriteString(fmt.Sprintf("%-12s %5.1f%% %8.2f %8.2f\n", expiration.Expiry.Format("Jan 2 2006"), expiration.IV, expiration.PutCallVolumeRatio, expiration.PutCallOpenInterestRatio))
+	}
+	b.WriteString("```")
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+		Name:  "Term Structure",
+		Value: b.String(),
+	})
+	return embed
+}
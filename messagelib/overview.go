@@ -0,0 +1,33 @@
+package messagelib
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// CreateOverviewEmbed creates a compact embed of many tickers side by side, e.g. a market overview.
+func CreateOverviewEmbed(title string, description string, tickers []*TickerValue) *discordgo.MessageEmbed {
+	return createOverviewEmbedWithPrefix(title, description, tickers, getTestServerID())
+}
+
+func createOverviewEmbedWithPrefix(title string, description string, tickers []*TickerValue, prefix string) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       title,
+		Description: description,
+		Footer:      createFooter(prefix, tickers...),
+	}
+	for _, ticker := range tickers {
+		value := "No Data"
+		if !math.IsNaN(float64(ticker.Value)) && ticker.Value != 0 {
+			value = fmt.Sprintf("%s\n%+.2f%%", formatTickerPrice(ticker, ticker.Value), ticker.Change)
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   ticker.Ticker,
+			Value:  value,
+			Inline: true,
+		})
+	}
+	return embed
+}
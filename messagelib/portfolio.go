@@ -0,0 +1,142 @@
+package messagelib
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord rejects embeds with more than 25 fields.
+const maxEmbedFields = 25
+
+// PositionValue passes the value of a single portfolio position.
+type PositionValue struct {
+	Ticker    string
+	Quantity  float64
+	CostBasis float64
+	Value     float64
+	// Unpriced is set when the position couldn't be quoted, in which case Value is zero.
+	Unpriced bool
+}
+
+// Gain returns the unrealized profit or loss of the position.
+func (p *PositionValue) Gain() float64 {
+	return p.Value - p.CostBasis
+}
+
+// PortfolioValue passes the value of a paper trading portfolio.
+type PortfolioValue struct {
+	UserID       string
+	Cash         float64
+	StartingCash float64
+	Positions    []*PositionValue
+}
+
+// TotalValue returns the cash plus the value of every priced position.
+func (p *PortfolioValue) TotalValue() float64 {
+	total := p.Cash
+	for _, position := range p.Positions {
+		total += position.Value
+	}
+	return total
+}
+
+// Unpriced returns whether any position couldn't be quoted, leaving it out of the total value.
+func (p *PortfolioValue) Unpriced() bool {
+	for _, position := range p.Positions {
+		if position.Unpriced {
+			return true
+		}
+	}
+	return false
+}
+
+// Return returns the percent change in total value since the portfolio was opened.
+func (p *PortfolioValue) Return() float64 {
+	if p.StartingCash == 0 {
+		return 0
+	}
+	return (p.TotalValue() - p.StartingCash) / p.StartingCash * 100
+}
+
+// CreatePortfolioEmbed creates an embed listing a portfolio's positions and profit and loss.
+func CreatePortfolioEmbed(portfolio *PortfolioValue) *discordgo.MessageEmbed {
+	return createPortfolioEmbedWithPrefix(portfolio, getTestServerID())
+}
+
+func createPortfolioEmbedWithPrefix(portfolio *PortfolioValue, prefix string) *discordgo.MessageEmbed {
+	var fields []*discordgo.MessageEmbedField
+	for _, position := range portfolio.Positions {
+		if len(fields) == maxEmbedFields {
+			break
+		}
+		fields = append(fields, createPositionEmbedField(position))
+	}
+	description := []string{
+		fmt.Sprintf("Owner: <@%s>", portfolio.UserID),
+		fmt.Sprintf("Total value: %s (%s)", formatMoney(portfolio.TotalValue()), formatPercent(portfolio.Return())),
+		fmt.Sprintf("Cash: %s", formatMoney(portfolio.Cash)),
+	}
+	if portfolio.Unpriced() {
+		description = append(description, "Positions with no data aren't included in the total value.")
+	}
+	return &discordgo.MessageEmbed{
+		Title:       "Portfolio",
+		Description: strings.Join(description, "\n"),
+		Fields:      fields,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: prefix,
+		},
+	}
+}
+
+func createPositionEmbedField(position *PositionValue) *discordgo.MessageEmbedField {
+	mesg := fmt.Sprintf("%s @ %s avg", formatFloat(float32(position.Quantity), 8), formatMoney(position.CostBasis/position.Quantity))
+	if position.Unpriced {
+		mesg = fmt.Sprintf("%s, No Data", mesg)
+	} else {
+		gainPercent := position.Gain() / position.CostBasis * 100
+		mesg = fmt.Sprintf("%s, worth %s (%s, %s)", mesg, formatMoney(position.Value), formatMoney(position.Gain()), formatPercent(gainPercent))
+	}
+	return &discordgo.MessageEmbedField{
+		Name:   position.Ticker,
+		Value:  mesg,
+		Inline: false,
+	}
+}
+
+// CreateLeaderboardEmbed creates an embed ranking portfolios, which must already be sorted with
+// any that couldn't be fully priced last. Those are listed without a rank or return.
+func CreateLeaderboardEmbed(portfolios []*PortfolioValue) *discordgo.MessageEmbed {
+	return createLeaderboardEmbedWithPrefix(portfolios, getTestServerID())
+}
+
+func createLeaderboardEmbedWithPrefix(portfolios []*PortfolioValue, prefix string) *discordgo.MessageEmbed {
+	var b strings.Builder
+	for i, portfolio := range portfolios {
+		if portfolio.Unpriced() {
+			b.WriteString(fmt.Sprintf("- <@%s> Unranked, some positions have no data\n", portfolio.UserID))
+			continue
+		}
+		b.WriteString(fmt.Sprintf("%d. <@%s> %s (%s)\n", i+1, portfolio.UserID, formatPercent(portfolio.Return()), formatMoney(portfolio.TotalValue())))
+	}
+	return &discordgo.MessageEmbed{
+		Title:       "Leaderboard",
+		Description: b.String(),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: prefix,
+		},
+	}
+}
+
+func formatMoney(value float64) string {
+	if value < 0 {
+		return fmt.Sprintf("-$%.2f", -value)
+	}
+	return fmt.Sprintf("$%.2f", value)
+}
+
+func formatPercent(value float64) string {
+	return fmt.Sprintf("%+.2f%%", value)
+}
@@ -0,0 +1,46 @@
+package messagelib
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// SearchResult passes a ticker found by name or suggested for a mistyped ticker.
+type SearchResult struct {
+	// Ticker is as it'd be typed in a message, e.g. "AAPL" or "$BTC".
+	Ticker string
+	Name   string
+	// Type is what kind of security the ticker is, e.g. "Common Stock", or "" if unknown.
+	Type string
+}
+
+// CreateSearchEmbed creates an embed listing the tickers found for a search.
+func CreateSearchEmbed(query string, results []*SearchResult) *discordgo.MessageEmbed {
+	return createSearchEmbedWithPrefix(query, results, getTestServerID())
+}
+
+func createSearchEmbedWithPrefix(query string, results []*SearchResult, prefix string) *discordgo.MessageEmbed {
+	var lines []string
+	for _, result := range results {
+		line := fmt.Sprintf("**%s**", result.Ticker)
+		if result.Name != "" {
+			line = fmt.Sprintf("%s · %s", line, result.Name)
+		}
+		if result.Type != "" {
+			line = fmt.Sprintf("%s · *%s*", line, result.Type)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "No matches.")
+	}
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Search: %s", query),
+		Description: strings.Join(lines, "\n"),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: prefix,
+		},
+	}
+}
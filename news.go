@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JoeParrinello/brokerbot/commandlib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/quotelib"
+	"github.com/JoeParrinello/brokerbot/stocklib"
+)
+
+const maxHeadlines = 5
+
+func init() {
+	commandlib.Register(&commandlib.Command{
+		Name:        "news",
+		Description: "Show the latest headlines for a company, or for the market",
+		Args: []commandlib.Arg{
+			{Name: "ticker", Description: "Company ticker, or $ for crypto news", Optional: true, Complete: completeTicker},
+		},
+		Handler: handleNews,
+	})
+}
+
+func handleNews(ctx context.Context, r *commandlib.Request) error {
+	title := "Market News"
+	var items []*messagelib.NewsItem
+	var err error
+	if tickers := messagelib.CanonicalizeMessage(messagelib.RemoveMentions(r.Args)); len(tickers) == 0 {
+		items, err = stocklib.GetGeneralNews(ctx, finnhubClient, stocklib.GeneralNews)
+	} else if ticker, class := quotelib.ParseTicker(tickers[0]); class == quotelib.Crypto {
+		// Finnhub only has company news for stocks, so crypto tickers get crypto market news.
+		title = "Crypto News"
+		items, err = stocklib.GetGeneralNews(ctx, finnhubClient, stocklib.CryptoNews)
+	} else {
+		title = fmt.Sprintf("%s News", ticker)
+		items, err = stocklib.GetCompanyNews(ctx, finnhubClient, ticker)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get news: %v", err)
+	}
+
+	if len(items) > maxHeadlines {
+		items = items[:maxHeadlines]
+	}
+	messagelib.ReplyMessageEmbed(r.Reply, messagelib.CreateNewsEmbed(title, items))
+	return nil
+}
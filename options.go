@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/JoeParrinello/brokerbot/commandlib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/optionslib"
+	"github.com/JoeParrinello/brokerbot/quotelib"
+)
+
+func init() {
+	commandlib.Register(&commandlib.Command{
+		Name:        "options",
+		Description: "Show a stock's option strikes around the money with bid/ask, open interest and IV",
+		Args: []commandlib.Arg{
+			{Name: "ticker", Description: "Stock ticker, e.g. AAPL", Complete: completeTicker},
+			{Name: "expiry", Description: "Expiration, e.g. 2026-12-18, 12/18 or DEC26. Defaults to the nearest", Optional: true},
+		},
+		Usage:   "options <ticker> [expiry]",
+		Handler: handleOptions,
+	})
+	commandlib.Register(&commandlib.Command{
+		Name:        "iv",
+		Description: "Summarize the implied volatility of a stock's options",
+		Args: []commandlib.Arg{
+			{Name: "ticker", Description: "Stock ticker, e.g. AAPL", Complete: completeTicker},
+		},
+		Usage:   "iv <ticker>",
+		Handler: handleIV,
+	})
+}
+
+func handleOptions(ctx context.Context, r *commandlib.Request) error {
+	ticker, err := optionableTicker(r.Args[0])
+	if err != nil {
+		return err
+	}
+	var expiry time.Time
+	if len(r.Args) > 1 {
+		if expiry, err = optionslib.ParseExpiry(r.Args[1], time.Now()); err != nil {
+			return err
+		}
+	}
+	chain, err := optionslib.GetChain(ctx, ticker, expiry)
+	if err != nil {
+		return err
+	}
+	messagelib.ReplyMessageEmbed(r.Reply, messagelib.CreateOptionChainEmbed(chain))
+	return nil
+}
+
+func handleIV(ctx context.Context, r *commandlib.Request) error {
+	ticker, err := optionableTicker(r.Args[0])
+	if err != nil {
+		return err
+	}
+	summary, err := optionslib.GetVolatility(ctx, ticker)
+	if err != nil {
+		return err
+	}
+	messagelib.ReplyMessageEmbed(r.Reply, messagelib.CreateVolatilityEmbed(summary))
+	return nil
+}
+
+// optionableTicker canonicalizes a ticker from a request, which must be a stock to have options.
+func optionableTicker(field string) (string, error) {
+	tickers := messagelib.CanonicalizeMessage(messagelib.RemoveMentions([]string{field}))
+	if len(tickers) == 0 {
+		return "", commandlib.ErrUsage
+	}
+	ticker, class := quotelib.ParseTicker(tickers[0])
+	if class != quotelib.Stock {
+		return "", errors.New("options are only available for stocks")
+	}
+	return ticker, nil
+}
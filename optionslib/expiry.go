@@ -0,0 +1,65 @@
+package optionslib
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JoeParrinello/brokerbot/marketlib"
+)
+
+// Layouts of expirations accepted by ParseExpiry. Fields in messages are split on spaces, so none
+// have any. Month names are matched without regard to case.
+var (
+	dateLayouts             = []string{"2006-01-02", "1/2/2006", "1/2/06"}
+	dateWithoutYearLayouts  = []string{"1/2"}
+	monthLayouts            = []string{"2006-01", "Jan06", "Jan2006"}
+	monthWithoutYearLayouts = []string{"Jan", "January"}
+)
+
+// ParseExpiry parses an option expiration typed in a message, e.g. "2026-12-18", "12/18/2026",
+// "12/18", "DEC26" or "DEC". Dates and months without a year are the next ones on or after now.
+// Months are their standard monthly expiration, the third Friday.
+func ParseExpiry(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	location := marketlib.Location()
+	now = now.In(location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, s, location); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range dateWithoutYearLayouts {
+		if t, err := time.ParseInLocation(layout, s, location); err == nil {
+			t = time.Date(now.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
+			if t.Before(today) {
+				t = t.AddDate(1, 0, 0)
+			}
+			return t, nil
+		}
+	}
+	for _, layout := range monthLayouts {
+		if t, err := time.ParseInLocation(layout, s, location); err == nil {
+			return monthlyExpiry(t.Year(), t.Month()), nil
+		}
+	}
+	for _, layout := range monthWithoutYearLayouts {
+		if t, err := time.ParseInLocation(layout, s, location); err == nil {
+			expiry := monthlyExpiry(now.Year(), t.Month())
+			if expiry.Before(today) {
+				expiry = monthlyExpiry(now.Year()+1, t.Month())
+			}
+			return expiry, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid expiration %q, expected e.g. 2026-12-18, 12/18 or DEC26", s)
+}
+
+// monthlyExpiry returns the third Friday of a month.
+func monthlyExpiry(year int, month time.Month) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, marketlib.Location())
+	offset := (int(time.Friday) - int(first.Weekday()) + 7) % 7
+	return first.AddDate(0, 0, offset+14)
+}
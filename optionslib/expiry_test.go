@@ -0,0 +1,77 @@
+package optionslib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JoeParrinello/brokerbot/marketlib"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, marketlib.Location())
+}
+
+func TestParseExpiry(t *testing.T) {
+	// A Saturday, after October's monthly expiration on the 16th.
+	now := time.Date(2026, time.October, 17, 12, 0, 0, 0, marketlib.Location())
+	tests := []struct {
+		s       string
+		want    time.Time
+		wantErr bool
+	}{
+		{s: "2026-12-18", want: date(2026, time.December, 18)},
+		{s: "12/18/2026", want: date(2026, time.December, 18)},
+		{s: "12/18/26", want: date(2026, time.December, 18)},
+		{s: "12/18", want: date(2026, time.December, 18)},
+		{s: "10/17", want: date(2026, time.October, 17)},
+		{s: "10/16", want: date(2027, time.October, 16)},
+		{s: "1/15", want: date(2027, time.January, 15)},
+		{s: "2026-12", want: date(2026, time.December, 18)},
+		{s: "DEC26", want: date(2026, time.December, 18)},
+		{s: "dec2026", want: date(2026, time.December, 18)},
+		{s: "DEC", want: date(2026, time.December, 18)},
+		{s: "OCT", want: date(2027, time.October, 15)},
+		{s: "October", want: date(2027, time.October, 15)},
+		{s: "JANUARY", want: date(2027, time.January, 15)},
+		{s: " 12/18 ", want: date(2026, time.December, 18)},
+		{s: "soon", wantErr: true},
+		{s: "13/45", wantErr: true},
+		{s: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseExpiry(tt.s, now)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseExpiry(%q) = %v, want error", tt.s, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseExpiry(%q) failed: %v", tt.s, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseExpiry(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestMonthlyExpiry(t *testing.T) {
+	tests := []struct {
+		year  int
+		month time.Month
+		want  time.Time
+	}{
+		{year: 2024, month: time.March, want: date(2024, time.March, 15)},
+		{year: 2025, month: time.February, want: date(2025, time.February, 21)},
+		// The month starts on a Friday.
+		{year: 2026, month: time.May, want: date(2026, time.May, 15)},
+		// The month starts the day after a Friday.
+		{year: 2026, month: time.August, want: date(2026, time.August, 21)},
+	}
+	for _, tt := range tests {
+		if got := monthlyExpiry(tt.year, tt.month); !got.Equal(tt.want) {
+			t.Errorf("monthlyExpiry(%d, %v) = %v, want %v", tt.year, tt.month, got, tt.want)
+		}
+	}
+}
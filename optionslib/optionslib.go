@@ -0,0 +1,282 @@
+package optionslib
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Finnhub-Stock-API/finnhub-go"
+	"github.com/JoeParrinello/brokerbot/cachelib"
+	"github.com/JoeParrinello/brokerbot/marketlib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/ratelimitlib"
+)
+
+// The Finnhub client doesn't cover option chains, so they're fetched from the API directly.
+const finnhubOptionChainURL = "https://finnhub.io/api/v1/stock/option-chain"
+
+const (
+	// LadderStrikes is how many strikes on each side of the money are shown in a ladder.
+	LadderStrikes = 5
+	// termStructureExpiries is how many expirations are summarized in a volatility summary.
+	termStructureExpiries = 6
+	// holidayShift is how far an expiration moves when the exchange is closed on its usual day.
+	holidayShift = 24 * time.Hour
+)
+
+var (
+	chainTTL   = flag.Duration("optionChainTTL", 5*time.Minute, "How long option chains are cached")
+	chainCache = cachelib.New("option chains", chainTTL)
+
+	optionsClient = &http.Client{
+		Timeout: time.Second * 30,
+	}
+)
+
+// optionChain is the response of Finnhub's option chain endpoint.
+type optionChain struct {
+	Code           string               `json:"code"`
+	LastTradePrice float64              `json:"lastTradePrice"`
+	Data           []*optionChainExpiry `json:"data"`
+}
+
+type optionChainExpiry struct {
+	ExpirationDate           string  `json:"expirationDate"`
+	ImpliedVolatility        float64 `json:"impliedVolatility"`
+	PutCallVolumeRatio       float64 `json:"putCallVolumeRatio"`
+	PutCallOpenInterestRatio float64 `json:"putCallOpenInterestRatio"`
+	Options                  struct {
+		Call []*optionContract `json:"CALL"`
+		Put  []*optionContract `json:"PUT"`
+	} `json:"options"`
+
+	expiry time.Time
+}
+
+type optionContract struct {
+	Strike            float64 `json:"strike"`
+	Bid               float64 `json:"bid"`
+	Ask               float64 `json:"ask"`
+	OpenInterest      float64 `json:"openInterest"`
+	ImpliedVolatility float64 `json:"impliedVolatility"`
+}
+
+// GetChain returns the strikes of a stock's options expiring on the expiry, or the nearest expiry
+// if it is zero, limited to LadderStrikes on each side of the money.
+func GetChain(ctx context.Context, ticker string, expiry time.Time) (*messagelib.OptionChain, error) {
+	chain, err := getChain(ctx, ticker)
+	if err != nil {
+		return nil, err
+	}
+	expiration, err := findExpiry(ticker, chain.Data, expiry)
+	if err != nil {
+		return nil, err
+	}
+
+	strikes := make(map[float64]*messagelib.OptionStrike)
+	get := func(strike float64) *messagelib.OptionStrike {
+		if strikes[strike] == nil {
+			strikes[strike] = &messagelib.OptionStrike{Strike: strike}
+		}
+		return strikes[strike]
+	}
+	for _, contract := range expiration.Options.Call {
+		get(contract.Strike).Call = optionQuote(contract)
+	}
+	for _, contract := range expiration.Options.Put {
+		get(contract.Strike).Put = optionQuote(contract)
+	}
+	ladder := make([]*messagelib.OptionStrike, 0, len(strikes))
+	for _, strike := range strikes {
+		ladder = append(ladder, strike)
+	}
+	sort.Slice(ladder, func(i, j int) bool {
+		return ladder[i].Strike < ladder[j].Strike
+	})
+
+	return &messagelib.OptionChain{
+		Ticker:  ticker,
+		Price:   chain.LastTradePrice,
+		Expiry:  expiration.expiry,
+		Strikes: aroundTheMoney(ladder, chain.LastTradePrice, LadderStrikes),
+	}, nil
+}
+
+func optionQuote(contract *optionContract) *messagelib.OptionQuote {
+	return &messagelib.OptionQuote{
+		Bid:          contract.Bid,
+		Ask:          contract.Ask,
+		OpenInterest: contract.OpenInterest,
+		IV:           contract.ImpliedVolatility,
+	}
+}
+
+// aroundTheMoney returns up to n strikes below and n strikes at or above the price.
+func aroundTheMoney(strikes []*messagelib.OptionStrike, price float64, n int) []*messagelib.OptionStrike {
+	i := sort.Search(len(strikes), func(i int) bool {
+		return strikes[i].Strike >= price
+	})
+	start, end := i-n, i+n
+	if start < 0 {
+		start = 0
+	}
+	if end > len(strikes) {
+		end = len(strikes)
+	}
+	return strikes[start:end]
+}
+
+// GetVolatility summarizes the implied volatility of a stock's options: at the money IV and
+// expected move for the nearest expiry, and the IV term structure of the following expiries.
+func GetVolatility(ctx context.Context, ticker string) (*messagelib.VolatilitySummary, error) {
+	chain, err := getChain(ctx, ticker)
+	if err != nil {
+		return nil, err
+	}
+	if len(chain.Data) == 0 {
+		return nil, fmt.Errorf("no options found for %q", ticker)
+	}
+
+	summary := &messagelib.VolatilitySummary{
+		Ticker: ticker,
+		Price:  chain.LastTradePrice,
+	}
+	for _, expiration := range chain.Data {
+		if len(summary.Expiries) == termStructureExpiries {
+			break
+		}
+		summary.Expiries = append(summary.Expiries, &messagelib.ExpiryVolatility{
+			Expiry:                   expiration.expiry,
+			IV:                       expiration.ImpliedVolatility,
+			PutCallVolumeRatio:       expiration.PutCallVolumeRatio,
+			PutCallOpenInterestRatio: expiration.PutCallOpenInterestRatio,
+		})
+	}
+
+	nearest := chain.Data[0]
+	summary.Expiry = nearest.expiry
+	summary.ATMIV = atTheMoneyIV(nearest, chain.LastTradePrice)
+	// A one standard deviation move by expiration, using calendar days like the IV it comes from.
+	days := math.Max(1, math.Ceil(time.Until(nearest.expiry).Hours()/24))
+	summary.ExpectedMove = chain.LastTradePrice * summary.ATMIV / 100 * math.Sqrt(days/365)
+	return summary, nil
+}
+
+// atTheMoneyIV averages the call and put IV at the strike nearest the price.
+func atTheMoneyIV(expiration *optionChainExpiry, price float64) float64 {
+	var ivs []float64
+	for _, contracts := range [][]*optionContract{expiration.Options.Call, expiration.Options.Put} {
+		var nearest *optionContract
+		for _, contract := range contracts {
+			if contract.ImpliedVolatility > 0 && (nearest == nil || math.Abs(contract.Strike-price) < math.Abs(nearest.Strike-price)) {
+				nearest = contract
+			}
+		}
+		if nearest != nil {
+			ivs = append(ivs, nearest.ImpliedVolatility)
+		}
+	}
+	if len(ivs) == 0 {
+		return expiration.ImpliedVolatility
+	}
+	var sum float64
+	for _, iv := range ivs {
+		sum += iv
+	}
+	return sum / float64(len(ivs))
+}
+
+// findExpiry returns the expiration on the expiry, or the nearest one if it is zero. Without an exact
+// match, an expiration a day off is used, since they move when the exchange is closed, e.g. to
+// Thursday for Good Friday.
+func findExpiry(ticker string, expirations []*optionChainExpiry, expiry time.Time) (*optionChainExpiry, error) {
+	if len(expirations) == 0 {
+		return nil, fmt.Errorf("no options found for %q", ticker)
+	}
+	if expiry.IsZero() {
+		return expirations[0], nil
+	}
+	for _, expiration := range expirations {
+		if expiration.expiry.Equal(expiry) {
+			return expiration, nil
+		}
+	}
+	var nearest []string
+	for _, expiration := range expirations {
+		diff := expiration.expiry.Sub(expiry)
+		if diff >= -holidayShift && diff <= holidayShift {
+			return expiration, nil
+		}
+		if diff > 0 && len(nearest) < 3 {
+			nearest = append(nearest, expiration.ExpirationDate)
+		}
+	}
+	if len(nearest) == 0 {
+		return nil, fmt.Errorf("no %s options expire on or after %s", ticker, expiry.Format("2006-01-02"))
+	}
+	return nil, fmt.Errorf("no %s options expire on %s, the next expirations are %s", ticker, expiry.Format("2006-01-02"), strings.Join(nearest, ", "))
+}
+
+// getChain returns the option chain of a stock with its expirations in date order.
+func getChain(ctx context.Context, ticker string) (*optionChain, error) {
+	chain, err := chainCache.Get(ticker, func() (interface{}, error) {
+		return fetchChain(ctx, ticker)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return chain.(*optionChain), nil
+}
+
+func fetchChain(ctx context.Context, ticker string) (*optionChain, error) {
+	apiKey, ok := ctx.Value(finnhub.ContextAPIKey).(finnhub.APIKey)
+	if !ok {
+		return nil, errors.New("no Finnhub API key in context")
+	}
+	query := url.Values{}
+	query.Set("symbol", ticker)
+	query.Set("token", apiKey.Key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, finnhubOptionChainURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for option chain: %v", err)
+	}
+
+	var chain optionChain
+	err = ratelimitlib.Finnhub.Do(ctx, func() (*http.Response, error) {
+		res, err := optionsClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return res, fmt.Errorf("option chain request for %q returned %s", ticker, res.Status)
+		}
+		return res, json.NewDecoder(res.Body).Decode(&chain)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get option chain: %v", err)
+	}
+
+	var expirations []*optionChainExpiry
+	for _, expiration := range chain.Data {
+		expiry, err := time.ParseInLocation("2006-01-02", expiration.ExpirationDate, marketlib.Location())
+		if err != nil {
+			continue
+		}
+		expiration.expiry = expiry
+		expirations = append(expirations, expiration)
+	}
+	sort.Slice(expirations, func(i, j int) bool {
+		return expirations[i].expiry.Before(expirations[j].expiry)
+	})
+	chain.Data = expirations
+	return &chain, nil
+}
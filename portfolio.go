@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/JoeParrinello/brokerbot/commandlib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/portfoliolib"
+)
+
+// Leaderboards only show the top portfolios to stay within Discord's embed limits.
+const maxLeaderboardSize = 25
+
+// Portfolios belong to a server, so there are none in direct messages.
+var errNoGuild = errors.New("paper trading is only available in servers")
+
+func init() {
+	commandlib.Register(&commandlib.Command{
+		Name:        portfoliolib.Buy,
+		Description: "Buy an asset in your paper trading portfolio",
+		Args: []commandlib.Arg{
+			{Name: "quantity", Description: "How much to buy, e.g. 10 or 0.5"},
+			{Name: "ticker", Description: "Ticker to buy, e.g. AAPL or $ETH", Complete: completeTicker},
+		},
+		Handler: handleTrade(portfoliolib.Buy),
+	})
+	commandlib.Register(&commandlib.Command{
+		Name:        portfoliolib.Sell,
+		Description: "Sell an asset in your paper trading portfolio",
+		Args: []commandlib.Arg{
+			{Name: "quantity", Description: "How much to sell, e.g. 10, 0.5 or all"},
+			{Name: "ticker", Description: "Ticker to sell, e.g. AAPL or $ETH", Complete: completeTicker},
+		},
+		Handler: handleTrade(portfoliolib.Sell),
+	})
+	commandlib.Register(&commandlib.Command{
+		Name:        "portfolio",
+		Description: "Show your paper trading portfolio",
+		Handler:     handlePortfolio,
+	})
+	commandlib.Register(&commandlib.Command{
+		Name:        "leaderboard",
+		Description: "Rank paper trading portfolios in this server by return",
+		Handler:     handleLeaderboard,
+	})
+}
+
+func handleTrade(side string) commandlib.Handler {
+	return func(ctx context.Context, r *commandlib.Request) error {
+		if r.GuildID == "" {
+			return errNoGuild
+		}
+		var quantity float64
+		if side != portfoliolib.Sell || !strings.EqualFold(r.Args[0], "all") {
+			var err error
+			quantity, err = strconv.ParseFloat(r.Args[0], 64)
+			if err != nil || quantity <= 0 {
+				return commandlib.ErrUsage
+			}
+		}
+		ticker := strings.ToUpper(r.Args[1])
+
+		trade, err := portfoliolib.Trade(ctx, r.GuildID, r.UserID, side, ticker, quantity)
+		if err != nil {
+			return fmt.Errorf("failed to %s %s: %v", side, ticker, err)
+		}
+		verb := "Bought"
+		if side == portfoliolib.Sell {
+			verb = "Sold"
+		}
+		messagelib.ReplyMessage(r.Reply, fmt.Sprintf("%s %g %s at %s", verb, trade.Quantity, trade.Ticker, messagelib.FormatPrice(float32(trade.Price))))
+		return nil
+	}
+}
+
+func handlePortfolio(ctx context.Context, r *commandlib.Request) error {
+	if r.GuildID == "" {
+		return errNoGuild
+	}
+	portfolio, err := portfoliolib.GetPortfolioValue(ctx, r.GuildID, r.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get portfolio: %v", err)
+	}
+	messagelib.ReplyMessageEmbed(r.Reply, messagelib.CreatePortfolioEmbed(portfolio))
+	return nil
+}
+
+func handleLeaderboard(ctx context.Context, r *commandlib.Request) error {
+	if r.GuildID == "" {
+		return errNoGuild
+	}
+	portfolios, err := portfoliolib.GetLeaderboard(ctx, r.GuildID)
+	if err != nil {
+		return fmt.Errorf("failed to get leaderboard: %v", err)
+	}
+	if len(portfolios) == 0 {
+		messagelib.ReplyMessage(r.Reply, fmt.Sprintf("Nobody has traded here yet, start with: %s buy <quantity> <ticker>", botPrefixes[0]))
+		return nil
+	}
+	if len(portfolios) > maxLeaderboardSize {
+		portfolios = portfolios[:maxLeaderboardSize]
+	}
+	messagelib.ReplyMessageEmbed(r.Reply, messagelib.CreateLeaderboardEmbed(portfolios))
+	return nil
+}
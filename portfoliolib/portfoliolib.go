@@ -0,0 +1,199 @@
+package portfoliolib
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/JoeParrinello/brokerbot/currencylib"
+	"github.com/JoeParrinello/brokerbot/firestorelib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/quotelib"
+)
+
+const (
+	Buy  = "buy"
+	Sell = "sell"
+
+	// Positions smaller than this are rounding errors left over from selling.
+	dustQuantity = 1e-9
+)
+
+var startingCash = flag.Float64("paperStartingCash", 100000, "Cash in each new paper trading portfolio.")
+
+// Trade buys or sells quantity of ticker at the current price in a user's portfolio.
+// A sell quantity of zero sells the whole position.
+func Trade(ctx context.Context, guildID string, userID string, side string, ticker string, quantity float64) (*firestorelib.Trade, error) {
+	price, err := getPrice(ctx, ticker)
+	if err != nil {
+		return nil, err
+	}
+
+	var trade *firestorelib.Trade
+	err = firestorelib.UpdatePortfolio(ctx, guildID, userID, func(p *firestorelib.Portfolio) (*firestorelib.Trade, error) {
+		var err error
+		trade, err = applyTrade(p, side, ticker, quantity, price, time.Now())
+		return trade, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return trade, nil
+}
+
+// getPrice returns the current price of ticker in USD, which portfolios hold their cash in.
+// Forex pairs and crypto markets quoted in other currencies are converted at the current rate.
+func getPrice(ctx context.Context, ticker string) (float64, error) {
+	quote, err := quotelib.GetQuote(ctx, ticker)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get quote for %q: %v", ticker, err)
+	}
+	if quote.Value == 0 {
+		return 0, fmt.Errorf("no data for %q", ticker)
+	}
+	if err := currencylib.ConvertQuote(ctx, quote, currencylib.USD); err != nil {
+		return 0, fmt.Errorf("failed to convert %q to %s: %v", ticker, currencylib.USD, err)
+	}
+	return float64(quote.Value), nil
+}
+
+// applyTrade buys or sells quantity of ticker at price in a portfolio, opening it if it's new.
+// A sell quantity of zero sells the whole position.
+func applyTrade(p *firestorelib.Portfolio, side string, ticker string, quantity float64, price float64, now time.Time) (*firestorelib.Trade, error) {
+	if p.Created.IsZero() {
+		p.Cash = *startingCash
+		p.StartingCash = *startingCash
+		p.Created = now
+	}
+	p.Updated = now
+
+	position, ok := p.Positions[ticker]
+	if !ok {
+		position = &firestorelib.Position{}
+		p.Positions[ticker] = position
+	}
+
+	tradeQuantity := quantity
+	switch side {
+	case Buy:
+		cost := price * quantity
+		if cost > p.Cash {
+			return nil, fmt.Errorf("not enough cash: %s costs $%.2f but you have $%.2f", ticker, cost, p.Cash)
+		}
+		p.Cash -= cost
+		position.Quantity += quantity
+		position.CostBasis += cost
+	case Sell:
+		if tradeQuantity == 0 {
+			tradeQuantity = position.Quantity
+		}
+		if position.Quantity == 0 || tradeQuantity > position.Quantity+dustQuantity {
+			return nil, fmt.Errorf("not enough %s: you have %g", ticker, position.Quantity)
+		}
+		position.CostBasis -= position.CostBasis * tradeQuantity / position.Quantity
+		position.Quantity -= tradeQuantity
+		p.Cash += price * tradeQuantity
+	default:
+		return nil, fmt.Errorf("unknown trade side %q", side)
+	}
+	if position.Quantity < dustQuantity {
+		delete(p.Positions, ticker)
+	}
+
+	return &firestorelib.Trade{
+		GuildID:  p.GuildID,
+		UserID:   p.UserID,
+		Ticker:   ticker,
+		Side:     side,
+		Quantity: tradeQuantity,
+		Price:    price,
+		Time:     now,
+	}, nil
+}
+
+// GetPortfolioValue returns a user's portfolio valued at current prices.
+func GetPortfolioValue(ctx context.Context, guildID string, userID string) (*messagelib.PortfolioValue, error) {
+	portfolio, err := firestorelib.GetPortfolio(ctx, guildID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if portfolio == nil {
+		return &messagelib.PortfolioValue{UserID: userID, Cash: *startingCash, StartingCash: *startingCash}, nil
+	}
+	return valuePortfolios(ctx, []*firestorelib.Portfolio{portfolio})[0], nil
+}
+
+// GetLeaderboard returns every portfolio in a guild valued at current prices, best return first.
+// Portfolios with positions that couldn't be quoted can't be ranked, so they come last.
+func GetLeaderboard(ctx context.Context, guildID string) ([]*messagelib.PortfolioValue, error) {
+	portfolios, err := firestorelib.GetPortfolios(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	values := valuePortfolios(ctx, portfolios)
+	sort.SliceStable(values, func(i, j int) bool {
+		if values[i].Unpriced() != values[j].Unpriced() {
+			return values[j].Unpriced()
+		}
+		return values[i].Return() > values[j].Return()
+	})
+	return values, nil
+}
+
+// valuePortfolios quotes every position across the portfolios, fetching each ticker once.
+// Positions whose ticker couldn't be quoted are marked unpriced.
+func valuePortfolios(ctx context.Context, portfolios []*firestorelib.Portfolio) []*messagelib.PortfolioValue {
+	tickers := make(map[string]bool)
+	for _, portfolio := range portfolios {
+		for ticker := range portfolio.Positions {
+			tickers[ticker] = true
+		}
+	}
+
+	prices := make(map[string]float64)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for ticker := range tickers {
+		wg.Add(1)
+		go func(ticker string) {
+			defer wg.Done()
+			price, err := getPrice(ctx, ticker)
+			if err != nil {
+				log.Printf("failed to price portfolio ticker %q: %v", ticker, err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			prices[ticker] = price
+		}(ticker)
+	}
+	wg.Wait()
+
+	values := make([]*messagelib.PortfolioValue, len(portfolios))
+	for i, portfolio := range portfolios {
+		value := &messagelib.PortfolioValue{
+			UserID:       portfolio.UserID,
+			Cash:         portfolio.Cash,
+			StartingCash: portfolio.StartingCash,
+		}
+		for ticker, position := range portfolio.Positions {
+			price, ok := prices[ticker]
+			value.Positions = append(value.Positions, &messagelib.PositionValue{
+				Ticker:    ticker,
+				Quantity:  position.Quantity,
+				CostBasis: position.CostBasis,
+				Value:     position.Quantity * price,
+				Unpriced:  !ok,
+			})
+		}
+		sort.Slice(value.Positions, func(i, j int) bool {
+			return value.Positions[i].Ticker < value.Positions[j].Ticker
+		})
+		values[i] = value
+	}
+	return values
+}
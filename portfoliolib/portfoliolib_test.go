@@ -0,0 +1,202 @@
+package portfoliolib
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/JoeParrinello/brokerbot/firestorelib"
+)
+
+func newPortfolio(cash float64, positions map[string]*firestorelib.Position) *firestorelib.Portfolio {
+	if positions == nil {
+		positions = make(map[string]*firestorelib.Position)
+	}
+	return &firestorelib.Portfolio{
+		GuildID:      "guild",
+		UserID:       "user",
+		Cash:         cash,
+		StartingCash: 1000,
+		Positions:    positions,
+		Created:      time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func approxEqual(a float64, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestApplyTrade(t *testing.T) {
+	now := time.Date(2024, time.March, 11, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		portfolio    *firestorelib.Portfolio
+		side         string
+		ticker       string
+		quantity     float64
+		price        float64
+		wantErr      bool
+		wantQuantity float64
+		wantCash     float64
+		// wantPosition is nil when the trade should leave no position.
+		wantPosition *firestorelib.Position
+	}{
+		{
+			name:         "buy",
+			portfolio:    newPortfolio(1000, nil),
+			side:         Buy,
+			ticker:       "AAPL",
+			quantity:     2,
+			price:        150,
+			wantQuantity: 2,
+			wantCash:     700,
+			wantPosition: &firestorelib.Position{Quantity: 2, CostBasis: 300},
+		},
+		{
+			name:         "buy more",
+			portfolio:    newPortfolio(1000, map[string]*firestorelib.Position{"AAPL": {Quantity: 2, CostBasis: 200}}),
+			side:         Buy,
+			ticker:       "AAPL",
+			quantity:     1,
+			price:        130,
+			wantQuantity: 1,
+			wantCash:     870,
+			wantPosition: &firestorelib.Position{Quantity: 3, CostBasis: 330},
+		},
+		{
+			name:         "buy fractional",
+			portfolio:    newPortfolio(1000, nil),
+			side:         Buy,
+			ticker:       "BTC",
+			quantity:     0.01,
+			price:        50000,
+			wantQuantity: 0.01,
+			wantCash:     500,
+			wantPosition: &firestorelib.Position{Quantity: 0.01, CostBasis: 500},
+		},
+		{
+			name:      "buy without enough cash",
+			portfolio: newPortfolio(100, nil),
+			side:      Buy,
+			ticker:    "AAPL",
+			quantity:  1,
+			price:     150,
+			wantErr:   true,
+		},
+		{
+			name:         "sell part",
+			portfolio:    newPortfolio(0, map[string]*firestorelib.Position{"AAPL": {Quantity: 4, CostBasis: 400}}),
+			side:         Sell,
+			ticker:       "AAPL",
+			quantity:     1,
+			price:        150,
+			wantQuantity: 1,
+			wantCash:     150,
+			wantPosition: &firestorelib.Position{Quantity: 3, CostBasis: 300},
+		},
+		{
+			name:         "sell all",
+			portfolio:    newPortfolio(0, map[string]*firestorelib.Position{"AAPL": {Quantity: 4, CostBasis: 400}}),
+			side:         Sell,
+			ticker:       "AAPL",
+			quantity:     0,
+			price:        50,
+			wantQuantity: 4,
+			wantCash:     200,
+		},
+		{
+			name:         "sell leaving dust",
+			portfolio:    newPortfolio(0, map[string]*firestorelib.Position{"ETH": {Quantity: 0.3, CostBasis: 300}}),
+			side:         Sell,
+			ticker:       "ETH",
+			quantity:     0.1 + 0.2,
+			price:        1000,
+			wantQuantity: 0.1 + 0.2,
+			wantCash:     300,
+		},
+		{
+			name:      "sell more than held",
+			portfolio: newPortfolio(0, map[string]*firestorelib.Position{"AAPL": {Quantity: 1, CostBasis: 100}}),
+			side:      Sell,
+			ticker:    "AAPL",
+			quantity:  2,
+			price:     150,
+			wantErr:   true,
+		},
+		{
+			name:      "sell without a position",
+			portfolio: newPortfolio(1000, nil),
+			side:      Sell,
+			ticker:    "AAPL",
+			quantity:  0,
+			price:     150,
+			wantErr:   true,
+		},
+		{
+			name:      "unknown side",
+			portfolio: newPortfolio(1000, nil),
+			side:      "short",
+			ticker:    "AAPL",
+			quantity:  1,
+			price:     150,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trade, err := applyTrade(tt.portfolio, tt.side, tt.ticker, tt.quantity, tt.price, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("applyTrade() = %+v, want error", trade)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyTrade() failed: %v", err)
+			}
+
+			want := firestorelib.Trade{GuildID: "guild", UserID: "user", Ticker: tt.ticker, Side: tt.side, Quantity: tt.wantQuantity, Price: tt.price, Time: now}
+			if !approxEqual(trade.Quantity, want.Quantity) {
+				t.Errorf("trade quantity = %g, want %g", trade.Quantity, want.Quantity)
+			}
+			trade.Quantity = want.Quantity
+			if *trade != want {
+				t.Errorf("applyTrade() = %+v, want %+v", *trade, want)
+			}
+			if !approxEqual(tt.portfolio.Cash, tt.wantCash) {
+				t.Errorf("cash = %g, want %g", tt.portfolio.Cash, tt.wantCash)
+			}
+			if !tt.portfolio.Updated.Equal(now) {
+				t.Errorf("updated = %v, want %v", tt.portfolio.Updated, now)
+			}
+
+			position, ok := tt.portfolio.Positions[tt.ticker]
+			if tt.wantPosition == nil {
+				if ok {
+					t.Errorf("position = %+v, want none", *position)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("position = none, want %+v", *tt.wantPosition)
+			}
+			if !approxEqual(position.Quantity, tt.wantPosition.Quantity) || !approxEqual(position.CostBasis, tt.wantPosition.CostBasis) {
+				t.Errorf("position = %+v, want %+v", *position, *tt.wantPosition)
+			}
+		})
+	}
+}
+
+func TestApplyTradeOpensPortfolio(t *testing.T) {
+	now := time.Date(2024, time.March, 11, 10, 0, 0, 0, time.UTC)
+	portfolio := &firestorelib.Portfolio{Positions: make(map[string]*firestorelib.Position)}
+	if _, err := applyTrade(portfolio, Buy, "AAPL", 1, 100, now); err != nil {
+		t.Fatalf("applyTrade() failed: %v", err)
+	}
+	if portfolio.StartingCash != *startingCash || !approxEqual(portfolio.Cash, *startingCash-100) {
+		t.Errorf("new portfolio cash = %g of %g, want %g of %g", portfolio.Cash, portfolio.StartingCash, *startingCash-100, *startingCash)
+	}
+	if !portfolio.Created.Equal(now) {
+		t.Errorf("new portfolio created = %v, want %v", portfolio.Created, now)
+	}
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JoeParrinello/brokerbot/chartlib"
+	"github.com/JoeParrinello/brokerbot/commandlib"
+	"github.com/JoeParrinello/brokerbot/cryptolib"
+	"github.com/JoeParrinello/brokerbot/currencylib"
+	"github.com/JoeParrinello/brokerbot/forexlib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/quotelib"
+	"github.com/JoeParrinello/brokerbot/statuszlib"
+)
+
+const (
+	quoteCommand = "quote"
+
+	// Each chart costs another provider call and a message embed, so keep requests small.
+	maxChartedTickers = 5
+)
+
+// chartTimeframes are the timeframes a quote of one ticker has buttons for.
+var chartTimeframes = []struct {
+	label     string
+	timeframe quotelib.Timeframe
+}{
+	{"1D", quotelib.OneDay},
+	{"1W", quotelib.FiveDays},
+	{"1M", quotelib.OneMonth},
+	{"1Y", quotelib.OneYear},
+}
+
+func init() {
+	commandlib.Register(&commandlib.Command{
+		Name:        quoteCommand,
+		Description: "Get quotes for tickers",
+		Args: []commandlib.Arg{
+			{Name: "tickers", Description: "Tickers to quote, e.g. AAPL $BTC EUR/USD ?TECH", Variadic: true, Complete: completeTicker},
+			{Name: "timeframe", Description: "Chart each ticker over this timeframe", Optional: true, Choices: quotelib.TimeframeNames()},
+			{Name: "currency", Description: "Currency to show prices in, e.g. EUR or BTC", Optional: true, Prefix: "in ", Complete: completeCurrency},
+		},
+		Usage:   fmt.Sprintf("[quote] <ticker> <ticker> ... [%s] [in <currency>]", strings.Join(quotelib.TimeframeNames(), "|")),
+		Handler: handleQuote,
+	})
+	commandlib.SetDefault(quoteCommand)
+}
+
+func handleQuote(ctx context.Context, r *commandlib.Request) error {
+	return replyWithQuotes(ctx, r, r.Args)
+}
+
+// replyWithQuotes expands and quotes tickers. If the fields include a timeframe, every ticker is charted over it.
+// Prices are shown in the currency the fields ask for, or else the guild's currency.
+func replyWithQuotes(ctx context.Context, r *commandlib.Request, fields []string) error {
+	fields, requestedCurrency, ok := splitCurrency(fields)
+	if !ok {
+		return commandlib.ErrUsage
+	}
+	currency := requestedCurrency
+	if currency == "" {
+		currency = currencylib.GetGuildCurrency(ctx, r.GuildID)
+	}
+	if _, err := currencylib.GetRate(ctx, currencylib.USD, currency); err != nil {
+		return err
+	}
+	tickers, timeframe, chartAll := splitTimeframe(fields)
+	tickers, err := expandTickers(ctx, r, tickers)
+	if err != nil {
+		return err
+	}
+	if len(tickers) == 0 {
+		return commandlib.ErrUsage
+	}
+
+	var notes []string
+	if chartAll && len(tickers) > maxChartedTickers {
+		notes = append(notes, fmt.Sprintf("Charts are limited to %d tickers.", maxChartedTickers))
+		chartAll = false
+	}
+
+	startTime := time.Now()
+	log.Printf("Received request for tickers: %s", tickers)
+
+	tickerValueChan := make(chan *messagelib.TickerValue, len(tickers))
+	failedTickerChan := make(chan string, len(tickers))
+	suggestionChan := make(chan []*messagelib.SearchResult, len(tickers))
+	var wg sync.WaitGroup
+	for _, rawTicker := range tickers {
+		wg.Add(1)
+
+		go func(rawTicker string) {
+			defer wg.Done()
+			ticker, tickerType := quotelib.ParseTicker(rawTicker)
+
+			provider, ok := quotelib.GetProvider(tickerType)
+			if !ok {
+				log.Printf("No quote provider for %s ticker: %q", tickerType, ticker)
+				failedTickerChan <- rawTicker
+				statuszlib.RecordError()
+				return
+			}
+
+			tickerCurrency := currency
+			if tickerType == quotelib.Forex {
+				// A pair's price is already in its quote currency, so converting it would be meaningless.
+				if _, quote, ok := forexlib.SplitPair(ticker); ok {
+					tickerCurrency = quote
+				}
+			}
+			tickerValue, err := currencylib.GetQuote(ctx, provider, ticker, tickerCurrency)
+			if err != nil {
+				log.Printf("Failed to get quote for %s ticker: %q: %v", tickerType, ticker, err)
+				failedTickerChan <- rawTicker
+				statuszlib.RecordError()
+				return
+			}
+			if chartAll || (shouldFetchCandles(tickerType) && len(tickers) == 1) {
+				chart, err := renderChart(ctx, provider, ticker, tickerCurrency, timeframe)
+				if err != nil {
+					log.Printf("Failed to chart %s candles: %q: %v", tickerType, ticker, err)
+					statuszlib.RecordError()
+				}
+				tickerValue.Chart = chart
+			}
+			if tickerValue.Value == 0 {
+				suggestionChan <- suggestTickers(ctx, rawTicker, ticker, tickerType)
+			}
+			tickerValueChan <- tickerValue
+		}(rawTicker)
+	}
+	wg.Wait()
+	close(tickerValueChan)
+	close(failedTickerChan)
+	close(suggestionChan)
+
+	var tv []*messagelib.TickerValue
+	for t := range tickerValueChan {
+		tv = append(tv, t)
+	}
+
+	var failedTickers []string
+	for t := range failedTickerChan {
+		failedTickers = append(failedTickers, t)
+	}
+	sort.Strings(failedTickers)
+	if len(tv) == 0 {
+		return fmt.Errorf("failed to get quotes for: %s (See logs)", strings.Join(failedTickers, ", "))
+	}
+
+	sort.Strings(tickers)
+	sort.SliceStable(tv, func(i, j int) bool {
+		r := strings.Compare(tv[i].Ticker, tv[j].Ticker)
+		return r < 0
+	})
+
+	msg := messagelib.CreateQuoteMessage(tv)
+	if len(failedTickers) > 0 {
+		// Reply with what we have rather than failing the whole request, e.g. when rate limited.
+		notes = append(notes, fmt.Sprintf("Couldn't get quotes for: %s (See logs)", strings.Join(failedTickers, ", ")))
+	}
+	buttons := quoteControls(tickers, timeframe, chartAll, requestedCurrency, tv)
+	var suggestions []*messagelib.SearchResult
+	for s := range suggestionChan {
+		suggestions = append(suggestions, s...)
+	}
+	if len(suggestions) > 0 {
+		// Suggestions come back in whichever order their lookups finished, so sort them for a stable message.
+		sort.SliceStable(suggestions, func(i, j int) bool {
+			return suggestions[i].Ticker < suggestions[j].Ticker
+		})
+		notes = append(notes, formatSuggestions(suggestions))
+		buttons = append(buttons, quoteButtons(suggestions))
+	}
+	msg.Components = messagelib.CreateCommandButtons(buttons...)
+	msg.Content = strings.Join(notes, "\n")
+	messagelib.ReplyMessageComplex(r.Reply, msg)
+	log.Printf("Sent response for tickers in %v: %s", time.Since(startTime), tickers)
+	return nil
+}
+
+// quoteControls returns rows of buttons for a quote message that edit it in place. Any quote can be
+// refreshed, and a quote of one ticker can also be charted over other timeframes or added to the
+// clicking user's watchlist.
+func quoteControls(tickers []string, timeframe quotelib.Timeframe, chartAll bool, currency string, tv []*messagelib.TickerValue) [][]*messagelib.CommandButton {
+	refreshTimeframe := ""
+	if chartAll {
+		refreshTimeframe = timeframe.Name
+	}
+	controls := []*messagelib.CommandButton{
+		{Label: "Refresh", Command: quoteCommandLine(tickers, refreshTimeframe, currency), Update: true},
+	}
+	if len(tickers) != 1 || len(tv) != 1 || tv[0].Value == 0 {
+		return [][]*messagelib.CommandButton{controls}
+	}
+
+	charted := tv[0].Chart != nil
+	if !charted {
+		controls = append(controls, &messagelib.CommandButton{
+			Label:   "Show Chart",
+			Command: quoteCommandLine(tickers, quotelib.DefaultTimeframe.Name, currency),
+			Update:  true,
+		})
+	}
+	controls = append(controls, &messagelib.CommandButton{
+		Label:   "Add to watchlist",
+		Command: fmt.Sprintf("watch add %s", tickers[0]),
+	})
+
+	var timeframes []*messagelib.CommandButton
+	for _, tf := range chartTimeframes {
+		timeframes = append(timeframes, &messagelib.CommandButton{
+			Label:   tf.label,
+			Command: quoteCommandLine(tickers, tf.timeframe.Name, currency),
+			Update:  true,
+			Primary: charted && tf.timeframe == timeframe,
+		})
+	}
+	return [][]*messagelib.CommandButton{controls, timeframes}
+}
+
+// quoteCommandLine returns the quote command for tickers, charted over the named timeframe and
+// in the currency unless they are "".
+func quoteCommandLine(tickers []string, timeframe string, currency string) string {
+	fields := append([]string{quoteCommand}, tickers...)
+	if timeframe != "" {
+		fields = append(fields, timeframe)
+	}
+	if currency != "" {
+		fields = append(fields, "in", currency)
+	}
+	return strings.Join(fields, " ")
+}
+
+// splitTimeframe separates a timeframe from the tickers in fields. It returns the
+// DefaultTimeframe and false if fields don't include a timeframe.
+func splitTimeframe(fields []string) ([]string, quotelib.Timeframe, bool) {
+	timeframe, ok := quotelib.DefaultTimeframe, false
+	var tickers []string
+	for _, field := range fields {
+		if tf, isTimeframe := quotelib.ParseTimeframe(field); isTimeframe {
+			timeframe, ok = tf, true
+			continue
+		}
+		tickers = append(tickers, field)
+	}
+	return tickers, timeframe, ok
+}
+
+// splitCurrency separates an "in <currency>" suffix from the tickers in fields. It returns
+// "" if fields don't name a currency, and false if "in" isn't followed by a currency code.
+func splitCurrency(fields []string) ([]string, string, bool) {
+	var currency string
+	var tickers []string
+	for i := 0; i < len(fields); i++ {
+		// Slash commands pass "in <currency>" as a single field.
+		words := strings.Fields(fields[i])
+		if len(words) == 0 || !strings.EqualFold(words[0], "in") {
+			tickers = append(tickers, fields[i])
+			continue
+		}
+		if len(words) == 1 {
+			if i++; i == len(fields) {
+				return nil, "", false
+			}
+			words = append(words, fields[i])
+		}
+		code, ok := currencylib.Parse(words[1])
+		if !ok || len(words) > 2 {
+			return nil, "", false
+		}
+		currency = code
+	}
+	return tickers, currency, true
+}
+
+// expandTickers canonicalizes tickers from a request and expands any aliases in them.
+func expandTickers(ctx context.Context, r *commandlib.Request, tickers []string) ([]string, error) {
+	tickers = messagelib.RemoveMentions(tickers)
+	tickers = messagelib.CanonicalizeMessage(tickers)
+	tickers, err := messagelib.ExpandAliases(ctx, r.GuildID, tickers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand aliases: %v", err)
+	}
+	return messagelib.DedupeSlice(tickers), nil
+}
+
+// renderChart fetches candles for the ticker in the currency over the timeframe and renders them as a PNG.
+func renderChart(ctx context.Context, provider quotelib.QuoteProvider, ticker string, currency string, timeframe quotelib.Timeframe) ([]byte, error) {
+	candles, err := currencylib.GetCandles(ctx, provider, ticker, currency, timeframe)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get candles: %v", err)
+	}
+	return chartlib.RenderCandles(candles, currency)
+}
+
+func shouldFetchCandles(class quotelib.AssetClass) bool {
+	switch class {
+	case quotelib.Stock:
+		return *fetchStockCandles
+	case quotelib.Crypto:
+		return *fetchCryptoCandles
+	case quotelib.Forex:
+		return *fetchForexCandles
+	}
+	return false
+}
+
+// completeTicker suggests aliases for "?" tickers and Gemini assets for "$" tickers.
+func completeTicker(ctx context.Context, r *commandlib.Request, partial string) []string {
+	partial = strings.ToUpper(partial)
+	switch {
+	case strings.HasPrefix(partial, "?"):
+		return completeAlias(ctx, r, partial)
+	case strings.HasPrefix(partial, "$"):
+		var assets []string
+		for _, feed := range cryptolib.GetLatestPriceFeed() {
+			if asset := strings.TrimSuffix(feed.Pair, "USD"); asset != feed.Pair && strings.HasPrefix("$"+asset, partial) {
+				assets = append(assets, "$"+asset)
+			}
+		}
+		return assets
+	}
+	return nil
+}
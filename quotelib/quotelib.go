@@ -0,0 +1,241 @@
+package quotelib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JoeParrinello/brokerbot/messagelib"
+)
+
+// AssetClass identifies which kind of market a ticker belongs to.
+type AssetClass int
+
+const (
+	Crypto AssetClass = iota
+	Stock
+	Forex
+)
+
+func (c AssetClass) String() string {
+	switch c {
+	case Crypto:
+		return "crypto"
+	case Stock:
+		return "stock"
+	case Forex:
+		return "forex"
+	}
+	return "unknown"
+}
+
+// ParseTicker splits a canonicalized ticker from a message into the provider ticker and its asset class.
+// Crypto tickers start with "$", and forex pairs are written "EUR/USD" or "%EURUSD". Well known
+// index, futures and fund names are resolved to their provider tickers, e.g. "SPX" to "^GSPC",
+// unless prefixed with "#" to quote the stock of the same name, e.g. "#ES".
+func ParseTicker(s string) (string, AssetClass) {
+	if strings.HasPrefix(s, "#") {
+		return strings.TrimPrefix(s, "#"), Stock
+	}
+	if symbol, ok := LookupSymbol(s); ok {
+		return symbol.Ticker, symbol.Class
+	}
+	switch {
+	case strings.HasPrefix(s, "$"):
+		return strings.TrimPrefix(s, "$"), Crypto
+	case strings.HasPrefix(s, "%"):
+		return strings.TrimPrefix(s, "%"), Forex
+	case len(s) == 7 && s[3] == '/':
+		return s[:3] + s[4:], Forex
+	}
+	return s, Stock
+}
+
+// Candle is a single OHLCV data point for an asset.
+type Candle struct {
+	Time   time.Time
+	Open   float32
+	High   float32
+	Low    float32
+	Close  float32
+	Volume float32
+}
+
+// Timeframe is the period of history covered by a chart.
+type Timeframe struct {
+	// Name is how the timeframe is typed in messages, e.g. "5d".
+	Name string
+	// Duration is the calendar time covered by the timeframe.
+	Duration time.Duration
+}
+
+var (
+	OneDay    = Timeframe{Name: "1d", Duration: 24 * time.Hour}
+	FiveDays  = Timeframe{Name: "5d", Duration: 5 * 24 * time.Hour}
+	OneMonth  = Timeframe{Name: "1m", Duration: 30 * 24 * time.Hour}
+	SixMonths = Timeframe{Name: "6m", Duration: 182 * 24 * time.Hour}
+	OneYear   = Timeframe{Name: "1y", Duration: 365 * 24 * time.Hour}
+	FiveYears = Timeframe{Name: "5y", Duration: 5 * 365 * 24 * time.Hour}
+
+	// Timeframes lists every supported Timeframe from shortest to longest.
+	Timeframes = []Timeframe{OneDay, FiveDays, OneMonth, SixMonths, OneYear, FiveYears}
+
+	// DefaultTimeframe is used for charts when no Timeframe is requested.
+	DefaultTimeframe = FiveDays
+)
+
+// ParseTimeframe returns the Timeframe named by s, ignoring case.
+func ParseTimeframe(s string) (Timeframe, bool) {
+	for _, tf := range Timeframes {
+		if strings.EqualFold(s, tf.Name) {
+			return tf, true
+		}
+	}
+	return Timeframe{}, false
+}
+
+// TimeframeNames returns the names of every supported Timeframe.
+func TimeframeNames() []string {
+	names := make([]string, len(Timeframes))
+	for i, tf := range Timeframes {
+		names[i] = tf.Name
+	}
+	return names
+}
+
+// QuoteProvider is a source of market data for a single asset class.
+type QuoteProvider interface {
+	// GetQuote returns the latest TickerValue for the ticker.
+	GetQuote(ctx context.Context, ticker string) (*messagelib.TickerValue, error)
+	// GetCandles returns candles covering the timeframe for the ticker, oldest first.
+	// Providers pick a candle resolution appropriate for the timeframe.
+	GetCandles(ctx context.Context, ticker string, timeframe Timeframe) ([]*Candle, error)
+	// GetName returns the display name of the ticker, or "" if unknown.
+	GetName(ctx context.Context, ticker string) (string, error)
+}
+
+// DetailProvider is implemented by providers that know more about a ticker than its price.
+type DetailProvider interface {
+	// GetDetail returns the quote and any trading, valuation and company details for the ticker.
+	GetDetail(ctx context.Context, ticker string) (*messagelib.TickerDetail, error)
+}
+
+// ErrNoMarket is returned by CurrencyProvider when a ticker isn't traded in the requested currency.
+var ErrNoMarket = errors.New("no market in currency")
+
+// CurrencyProvider is implemented by providers with markets quoted in currencies other than USD.
+type CurrencyProvider interface {
+	// GetQuoteIn returns the latest TickerValue for the ticker from its market in the currency,
+	// or ErrNoMarket if it has none.
+	GetQuoteIn(ctx context.Context, ticker string, currency string) (*messagelib.TickerValue, error)
+	// GetCandlesIn returns candles covering the timeframe for the ticker from its market in the
+	// currency, or ErrNoMarket if it has none.
+	GetCandlesIn(ctx context.Context, ticker string, currency string, timeframe Timeframe) ([]*Candle, error)
+}
+
+// Suggester is implemented by providers that can look up tickers by name or suggest ones close to a mistyped ticker.
+type Suggester interface {
+	// Suggest returns tickers that the query may mean, best first, as they'd be typed in a message, e.g. "$BTC".
+	Suggest(ctx context.Context, query string) ([]*messagelib.SearchResult, error)
+}
+
+var (
+	mu        sync.RWMutex
+	providers = make(map[AssetClass]QuoteProvider)
+)
+
+// RegisterProvider sets the QuoteProvider used for an asset class, replacing any existing one.
+func RegisterProvider(class AssetClass, provider QuoteProvider) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers[class] = provider
+}
+
+// GetProvider returns the QuoteProvider registered for an asset class.
+func GetProvider(class AssetClass) (QuoteProvider, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	provider, ok := providers[class]
+	return provider, ok
+}
+
+// GetQuote parses a canonicalized ticker and fetches its quote from the registered provider.
+func GetQuote(ctx context.Context, rawTicker string) (*messagelib.TickerValue, error) {
+	ticker, class := ParseTicker(rawTicker)
+	provider, ok := GetProvider(class)
+	if !ok {
+		return nil, fmt.Errorf("no quote provider for %s ticker %q", class, ticker)
+	}
+	return provider.GetQuote(ctx, ticker)
+}
+
+// GetQuotes fetches quotes for canonicalized tickers concurrently. Quotes are returned in
+// the order of tickers, followed by the tickers that couldn't be quoted.
+func GetQuotes(ctx context.Context, rawTickers []string) ([]*messagelib.TickerValue, []string) {
+	quotes := make([]*messagelib.TickerValue, len(rawTickers))
+	errs := make([]error, len(rawTickers))
+	var wg sync.WaitGroup
+	for i, rawTicker := range rawTickers {
+		wg.Add(1)
+		go func(i int, rawTicker string) {
+			defer wg.Done()
+			quotes[i], errs[i] = GetQuote(ctx, rawTicker)
+		}(i, rawTicker)
+	}
+	wg.Wait()
+
+	var ret []*messagelib.TickerValue
+	var failed []string
+	for i, quote := range quotes {
+		if errs[i] != nil {
+			log.Printf("failed to get quote for %q: %v", rawTickers[i], errs[i])
+			failed = append(failed, rawTickers[i])
+			continue
+		}
+		ret = append(ret, quote)
+	}
+	return ret, failed
+}
+
+// Suggest returns up to n tickers that the query may mean from every provider that is a Suggester,
+// starting with the provider of the class, e.g. the class of a ticker that couldn't be quoted.
+func Suggest(ctx context.Context, query string, class AssetClass, n int) []*messagelib.SearchResult {
+	classes := []AssetClass{class}
+	for _, c := range []AssetClass{Stock, Crypto, Forex} {
+		if c != class {
+			classes = append(classes, c)
+		}
+	}
+
+	var ret []*messagelib.SearchResult
+	seen := make(map[string]bool)
+	for _, c := range classes {
+		provider, ok := GetProvider(c)
+		if !ok {
+			continue
+		}
+		suggester, ok := provider.(Suggester)
+		if !ok {
+			continue
+		}
+		results, err := suggester.Suggest(ctx, query)
+		if err != nil {
+			log.Printf("failed to get %s suggestions for %q: %v", c, query, err)
+			continue
+		}
+		for _, result := range results {
+			if len(ret) == n {
+				return ret
+			}
+			if !seen[result.Ticker] {
+				seen[result.Ticker] = true
+				ret = append(ret, result)
+			}
+		}
+	}
+	return ret
+}
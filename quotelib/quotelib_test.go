@@ -0,0 +1,79 @@
+package quotelib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTicker(t *testing.T) {
+	tests := []struct {
+		s          string
+		wantTicker string
+		wantClass  AssetClass
+	}{
+		{s: "AAPL", wantTicker: "AAPL", wantClass: Stock},
+		{s: "BRK.B", wantTicker: "BRK.B", wantClass: Stock},
+		{s: "$BTC", wantTicker: "BTC", wantClass: Crypto},
+		{s: "%EURUSD", wantTicker: "EURUSD", wantClass: Forex},
+		{s: "EUR/USD", wantTicker: "EURUSD", wantClass: Forex},
+		{s: "SPX", wantTicker: "^GSPC", wantClass: Stock},
+		{s: "^GSPC", wantTicker: "^GSPC", wantClass: Stock},
+		{s: "VIX", wantTicker: "^VIX", wantClass: Stock},
+		{s: "ES", wantTicker: "SPX500_USD", wantClass: Forex},
+		{s: "/ES", wantTicker: "SPX500_USD", wantClass: Forex},
+		{s: "ES=F", wantTicker: "SPX500_USD", wantClass: Forex},
+		{s: "CL", wantTicker: "WTICO_USD", wantClass: Forex},
+		{s: "OIL", wantTicker: "WTICO_USD", wantClass: Forex},
+		{s: "#ES", wantTicker: "ES", wantClass: Stock},
+		{s: "#CL", wantTicker: "CL", wantClass: Stock},
+		{s: "SPY", wantTicker: "SPY", wantClass: Stock},
+	}
+	for _, tt := range tests {
+		ticker, class := ParseTicker(tt.s)
+		if ticker != tt.wantTicker || class != tt.wantClass {
+			t.Errorf("ParseTicker(%q) = %q, %v, want %q, %v", tt.s, ticker, class, tt.wantTicker, tt.wantClass)
+		}
+	}
+}
+
+func TestSymbols(t *testing.T) {
+	aliases := make(map[string]*Symbol)
+	tickers := make(map[AssetClass]map[string]*Symbol)
+	for _, symbol := range symbols {
+		if len(symbol.Aliases) == 0 {
+			t.Errorf("symbol %q has no aliases", symbol.Ticker)
+			continue
+		}
+		if symbol.Name == "" {
+			t.Errorf("symbol %q has no name", symbol.Alias())
+		}
+		for _, alias := range symbol.Aliases {
+			// Tickers are upcased before they're looked up, and the prefixes mark other kinds of ticker.
+			if alias != strings.ToUpper(alias) {
+				t.Errorf("alias %q of %q isn't upper case", alias, symbol.Name)
+			}
+			if strings.ContainsAny(alias[:1], "$%#?") {
+				t.Errorf("alias %q of %q starts with a ticker prefix", alias, symbol.Name)
+			}
+			if other, ok := aliases[alias]; ok {
+				t.Errorf("alias %q is used by both %q and %q", alias, other.Name, symbol.Name)
+			}
+			aliases[alias] = symbol
+
+			if got, ok := LookupSymbol(alias); !ok || got != symbol {
+				t.Errorf("LookupSymbol(%q) = %v, %v, want %q", alias, got, ok, symbol.Name)
+			}
+		}
+		if other, ok := tickers[symbol.Class][symbol.Ticker]; ok {
+			t.Errorf("%v ticker %q is used by both %q and %q", symbol.Class, symbol.Ticker, other.Name, symbol.Name)
+		}
+		if tickers[symbol.Class] == nil {
+			tickers[symbol.Class] = make(map[string]*Symbol)
+		}
+		tickers[symbol.Class][symbol.Ticker] = symbol
+
+		if got, ok := LookupTicker(symbol.Class, symbol.Ticker); !ok || got != symbol {
+			t.Errorf("LookupTicker(%v, %q) = %v, %v, want %q", symbol.Class, symbol.Ticker, got, ok, symbol.Name)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package quotelib
+
+// Symbol is a market known by common names that differ from, or add a friendly name to, its provider ticker.
+type Symbol struct {
+	// Aliases are the names the market can be typed as. The first is used when displaying it.
+	Aliases []string
+	// Name is the display name of the market, e.g. "S&P 500".
+	Name string
+	// Class and Ticker identify the market to its provider.
+	Class  AssetClass
+	Ticker string
+}
+
+// Alias returns the name the symbol is displayed as, e.g. "SPX".
+func (s *Symbol) Alias() string {
+	return s.Aliases[0]
+}
+
+// symbols lists well known indices, futures and ETFs. Futures can be typed by their root, e.g. ES,
+// with a leading "/" as in most brokerages, or Yahoo style with "=F". Roots that are also stock
+// tickers, e.g. ES (Eversource) or CL (Colgate), resolve to the future, so the stock is typed
+// with the stock prefix, e.g. "#ES".
+//
+// Indices are Finnhub's index tickers. Futures are quoted from OANDA's CFDs on the same
+// underlying, which track the front month contract closely.
+var symbols = []*Symbol{
+	{Aliases: []string{"SPX", "SP500", "^GSPC"}, Name: "S&P 500", Class: Stock, Ticker: "^GSPC"},
+	{Aliases: []string{"NDX", "^NDX"}, Name: "Nasdaq 100", Class: Stock, Ticker: "^NDX"},
+	{Aliases: []string{"IXIC", "NASDAQ", "^IXIC"}, Name: "Nasdaq Composite", Class: Stock, Ticker: "^IXIC"},
+	{Aliases: []string{"DJI", "DJIA", "^DJI"}, Name: "Dow Jones Industrial Average", Class: Stock, Ticker: "^DJI"},
+	{Aliases: []string{"RUT", "^RUT"}, Name: "Russell 2000", Class: Stock, Ticker: "^RUT"},
+	{Aliases: []string{"VIX", "^VIX"}, Name: "CBOE Volatility Index", Class: Stock, Ticker: "^VIX"},
+
+	{Aliases: []string{"/ES", "ES", "ES=F"}, Name: "S&P 500 Futures", Class: Forex, Ticker: "SPX500_USD"},
+	{Aliases: []string{"/NQ", "NQ", "NQ=F"}, Name: "Nasdaq 100 Futures", Class: Forex, Ticker: "NAS100_USD"},
+	{Aliases: []string{"/YM", "YM", "YM=F"}, Name: "Dow Futures", Class: Forex, Ticker: "US30_USD"},
+	{Aliases: []string{"/RTY", "RTY", "RTY=F"}, Name: "Russell 2000 Futures", Class: Forex, Ticker: "US2000_USD"},
+	{Aliases: []string{"/GC", "GC", "GC=F", "XAU"}, Name: "Gold", Class: Forex, Ticker: "XAU_USD"},
+	{Aliases: []string{"/SI", "SI", "SI=F", "XAG", "SILVER"}, Name: "Silver", Class: Forex, Ticker: "XAG_USD"},
+	{Aliases: []string{"/CL", "CL", "CL=F", "WTI", "CRUDE", "OIL"}, Name: "WTI Crude Oil", Class: Forex, Ticker: "WTICO_USD"},
+	{Aliases: []string{"/BZ", "BZ", "BZ=F", "BRENT"}, Name: "Brent Crude Oil", Class: Forex, Ticker: "BCO_USD"},
+	{Aliases: []string{"/NG", "NG", "NG=F", "NATGAS"}, Name: "Natural Gas", Class: Forex, Ticker: "NATGAS_USD"},
+
+	// Finnhub has no company profile for funds, so they'd otherwise be shown without a name.
+	{Aliases: []string{"SPY"}, Name: "SPDR S&P 500 ETF", Class: Stock, Ticker: "SPY"},
+	{Aliases: []string{"VOO"}, Name: "Vanguard S&P 500 ETF", Class: Stock, Ticker: "VOO"},
+	{Aliases: []string{"VTI"}, Name: "Vanguard Total Stock Market ETF", Class: Stock, Ticker: "VTI"},
+	{Aliases: []string{"QQQ"}, Name: "Invesco QQQ", Class: Stock, Ticker: "QQQ"},
+	{Aliases: []string{"DIA"}, Name: "SPDR Dow Jones Industrial Average ETF", Class: Stock, Ticker: "DIA"},
+	{Aliases: []string{"IWM"}, Name: "iShares Russell 2000 ETF", Class: Stock, Ticker: "IWM"},
+	{Aliases: []string{"TLT"}, Name: "iShares 20+ Year Treasury Bond ETF", Class: Stock, Ticker: "TLT"},
+	{Aliases: []string{"GLD"}, Name: "SPDR Gold Shares", Class: Stock, Ticker: "GLD"},
+	{Aliases: []string{"SLV"}, Name: "iShares Silver Trust", Class: Stock, Ticker: "SLV"},
+	{Aliases: []string{"USO"}, Name: "United States Oil Fund", Class: Stock, Ticker: "USO"},
+}
+
+var (
+	symbolsByAlias  = make(map[string]*Symbol)
+	symbolsByTicker = make(map[AssetClass]map[string]*Symbol)
+)
+
+func init() {
+	for _, symbol := range symbols {
+		for _, alias := range symbol.Aliases {
+			symbolsByAlias[alias] = symbol
+		}
+		if symbolsByTicker[symbol.Class] == nil {
+			symbolsByTicker[symbol.Class] = make(map[string]*Symbol)
+		}
+		symbolsByTicker[symbol.Class][symbol.Ticker] = symbol
+	}
+}
+
+// LookupSymbol returns the Symbol a canonicalized ticker from a message is an alias of.
+func LookupSymbol(s string) (*Symbol, bool) {
+	symbol, ok := symbolsByAlias[s]
+	return symbol, ok
+}
+
+// LookupTicker returns the Symbol of a provider ticker, so providers can display it by its alias and name.
+func LookupTicker(class AssetClass, ticker string) (*Symbol, bool) {
+	symbol, ok := symbolsByTicker[class][ticker]
+	return symbol, ok
+}
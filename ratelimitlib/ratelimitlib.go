@@ -0,0 +1,201 @@
+package ratelimitlib
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrRateLimited is returned when a call can't be made within the limiter's maximum wait.
+var ErrRateLimited = errors.New("rate limited, try again shortly")
+
+const (
+	maxRetries     = 3
+	initialBackoff = time.Second
+)
+
+var (
+	finnhubCallsPerMinute = flag.Int("finnhubCallsPerMinute", 60, "Maximum Finnhub API calls per minute.")
+	finnhubBurst          = flag.Int("finnhubBurst", 10, "Maximum Finnhub API calls made at once before rate limiting.")
+	finnhubMaxWait        = flag.Duration("finnhubMaxWait", 15*time.Second, "Longest a request will queue for a Finnhub API call before giving up.")
+
+	// Finnhub limits calls to the Finnhub API.
+	Finnhub = New("finnhub", finnhubCallsPerMinute, finnhubBurst, finnhubMaxWait)
+
+	mu       sync.Mutex
+	limiters []*Limiter
+)
+
+// Limiter is a token bucket that queues callers until they can make a call.
+type Limiter struct {
+	name      string
+	perMinute *int
+	burst     *int
+	maxWait   *time.Duration
+
+	mu          sync.Mutex
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+
+	queued    int32
+	calls     int64
+	throttled int64
+	rejected  int64
+}
+
+// Stats is a snapshot of a limiter's counters.
+type Stats struct {
+	Name string
+	// Queued is the number of calls currently waiting for a token.
+	Queued int32
+	Calls  int64
+	// Throttled calls were answered with 429 Too Many Requests.
+	Throttled int64
+	// Rejected calls gave up waiting for a token.
+	Rejected int64
+}
+
+// New creates a limiter. The limits are read on each call, so they may point at flags that haven't been parsed yet.
+func New(name string, perMinute *int, burst *int, maxWait *time.Duration) *Limiter {
+	l := &Limiter{
+		name:      name,
+		perMinute: perMinute,
+		burst:     burst,
+		maxWait:   maxWait,
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	limiters = append(limiters, l)
+	return l
+}
+
+// Wait blocks until a call may be made, or returns ErrRateLimited if that would take longer than the maximum wait.
+func (l *Limiter) Wait(ctx context.Context) error {
+	delay, ok := l.reserve()
+	if !ok {
+		atomic.AddInt64(&l.rejected, 1)
+		return ErrRateLimited
+	}
+	if delay <= 0 {
+		return nil
+	}
+
+	atomic.AddInt32(&l.queued, 1)
+	defer atomic.AddInt32(&l.queued, -1)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.cancel()
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token, returning how long the caller must wait before using it.
+// Tokens may go negative, which queues callers in the order they reserved.
+func (l *Limiter) reserve() (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	rate := float64(*l.perMinute) / 60 // tokens per second
+	if l.last.IsZero() {
+		// Start with a full bucket.
+		l.tokens = float64(*l.burst)
+	} else {
+		l.tokens += now.Sub(l.last).Seconds() * rate
+	}
+	if l.tokens > float64(*l.burst) {
+		l.tokens = float64(*l.burst)
+	}
+	l.last = now
+
+	delay := time.Duration(0)
+	if l.tokens < 1 {
+		delay = time.Duration((1 - l.tokens) / rate * float64(time.Second))
+	}
+	if paused := l.pausedUntil.Sub(now); paused > delay {
+		delay = paused
+	}
+	if delay > *l.maxWait {
+		return 0, false
+	}
+	l.tokens--
+	return delay, true
+}
+
+func (l *Limiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens++
+}
+
+// pause stops all calls for d, e.g. after the API reports we're over its limit.
+func (l *Limiter) pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// Do waits for a token and runs call, backing off and retrying while the API responds 429 Too Many Requests.
+func (l *Limiter) Do(ctx context.Context, call func() (*http.Response, error)) error {
+	backoff := initialBackoff
+	for attempt := 0; ; attempt++ {
+		if err := l.Wait(ctx); err != nil {
+			return err
+		}
+		atomic.AddInt64(&l.calls, 1)
+		res, err := call()
+		if res == nil || res.StatusCode != http.StatusTooManyRequests {
+			return err
+		}
+
+		atomic.AddInt64(&l.throttled, 1)
+		if attempt == maxRetries {
+			return ErrRateLimited
+		}
+		delay := backoff
+		if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil {
+			delay = time.Duration(seconds) * time.Second
+		}
+		l.pause(delay)
+		backoff *= 2
+	}
+}
+
+// Stats returns a snapshot of the limiter's counters.
+func (l *Limiter) Stats() Stats {
+	return Stats{
+		Name:      l.name,
+		Queued:    atomic.LoadInt32(&l.queued),
+		Calls:     atomic.LoadInt64(&l.calls),
+		Throttled: atomic.LoadInt64(&l.throttled),
+		Rejected:  atomic.LoadInt64(&l.rejected),
+	}
+}
+
+// GetStats returns the stats of every limiter, sorted by name.
+func GetStats() []Stats {
+	mu.Lock()
+	defer mu.Unlock()
+	stats := make([]Stats, len(limiters))
+	for i, l := range limiters {
+		stats[i] = l.Stats()
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
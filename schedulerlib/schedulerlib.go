@@ -0,0 +1,90 @@
+package schedulerlib
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/JoeParrinello/brokerbot/marketlib"
+	"github.com/JoeParrinello/brokerbot/shutdownlib"
+)
+
+// Markets are never closed for longer than a long weekend plus a holiday, so this bounds searches for the last trading day.
+const maxDaysSkipped = 10
+
+// Every runs job every interval in the background until shutdown. The job is never run concurrently with itself.
+func Every(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				job(ctx)
+			}
+		}
+	}()
+
+	shutdownlib.AddShutdownHandler(func() error {
+		log.Printf("BrokerBot shutting down %s.", name)
+		cancel()
+		<-done
+		return nil
+	})
+}
+
+// Schedule is a time of day in a time zone.
+type Schedule struct {
+	Hour     int
+	Minute   int
+	Location *time.Location
+	// TradingDaysOnly skips days the US stock market is closed.
+	TradingDaysOnly bool
+}
+
+// ParseSchedule parses a 24 hour time of day such as "09:35" in the named time zone.
+func ParseSchedule(clock string, zone string, tradingDaysOnly bool) (*Schedule, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time of day %q, expected HH:MM", clock)
+	}
+	location, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", zone)
+	}
+	return &Schedule{
+		Hour:            t.Hour(),
+		Minute:          t.Minute(),
+		Location:        location,
+		TradingDaysOnly: tradingDaysOnly,
+	}, nil
+}
+
+// LastDue returns the most recent scheduled time at or before now, or zero if there isn't one in the last few days.
+func (s *Schedule) LastDue(now time.Time) time.Time {
+	now = now.In(s.Location)
+	for i := 0; i <= maxDaysSkipped; i++ {
+		day := now.AddDate(0, 0, -i)
+		due := time.Date(day.Year(), day.Month(), day.Day(), s.Hour, s.Minute, 0, 0, s.Location)
+		if due.After(now) {
+			continue
+		}
+		if s.TradingDaysOnly && !marketlib.GetDay(due).IsTradingDay() {
+			continue
+		}
+		return due
+	}
+	return time.Time{}
+}
+
+// String returns the schedule in the form ParseSchedule accepts, e.g. "09:35 America/New_York".
+func (s *Schedule) String() string {
+	return fmt.Sprintf("%02d:%02d %s", s.Hour, s.Minute, s.Location)
+}
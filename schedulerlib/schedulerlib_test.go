@@ -0,0 +1,70 @@
+package schedulerlib
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		clock   string
+		zone    string
+		want    string
+		wantErr bool
+	}{
+		{clock: "09:35", zone: "America/New_York", want: "09:35 America/New_York"},
+		{clock: "23:05", zone: "UTC", want: "23:05 UTC"},
+		{clock: "noon", zone: "UTC", wantErr: true},
+		{clock: "24:00", zone: "UTC", wantErr: true},
+		{clock: "09:35", zone: "Mars/Olympus_Mons", wantErr: true},
+	}
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.clock, tt.zone, false)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseSchedule(%q, %q) = %v, want error", tt.clock, tt.zone, schedule)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseSchedule(%q, %q) failed: %v", tt.clock, tt.zone, err)
+			continue
+		}
+		if got := schedule.String(); got != tt.want {
+			t.Errorf("ParseSchedule(%q, %q) = %q, want %q", tt.clock, tt.zone, got, tt.want)
+		}
+	}
+}
+
+func TestLastDue(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(year int, month time.Month, day int, hour int, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, newYork)
+	}
+	tests := []struct {
+		name            string
+		tradingDaysOnly bool
+		now             time.Time
+		want            time.Time
+	}{
+		{name: "later today", now: at(2024, time.March, 12, 10, 0), want: at(2024, time.March, 12, 9, 35)},
+		{name: "exactly due", now: at(2024, time.March, 12, 9, 35), want: at(2024, time.March, 12, 9, 35)},
+		{name: "earlier today", now: at(2024, time.March, 12, 9, 34), want: at(2024, time.March, 11, 9, 35)},
+		{name: "daily on a weekend", now: at(2024, time.March, 10, 12, 0), want: at(2024, time.March, 10, 9, 35)},
+		{name: "trading days on a weekend", tradingDaysOnly: true, now: at(2024, time.March, 10, 12, 0), want: at(2024, time.March, 8, 9, 35)},
+		{name: "trading days on monday morning", tradingDaysOnly: true, now: at(2024, time.March, 11, 8, 0), want: at(2024, time.March, 8, 9, 35)},
+		{name: "trading days after a holiday", tradingDaysOnly: true, now: at(2024, time.January, 2, 8, 0), want: at(2023, time.December, 29, 9, 35)},
+		{name: "now in another zone", now: time.Date(2024, time.March, 12, 13, 35, 0, 0, time.UTC), want: at(2024, time.March, 12, 9, 35)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := &Schedule{Hour: 9, Minute: 35, Location: newYork, TradingDaysOnly: tt.tradingDaysOnly}
+			if got := schedule.LastDue(tt.now); !got.Equal(tt.want) {
+				t.Errorf("LastDue(%v) = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/JoeParrinello/brokerbot/commandlib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/quotelib"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// maxSearchResults is how many matches a search lists, each with a button to quote it.
+	maxSearchResults = 10
+	// maxSuggestions is how many tickers are suggested for each ticker without a quote.
+	maxSuggestions = 3
+)
+
+func init() {
+	commandlib.Register(&commandlib.Command{
+		Name:        "search",
+		Description: "Find tickers by company or coin name",
+		Args: []commandlib.Arg{
+			{Name: "query", Description: "Name or ticker to search for, e.g. apple", Variadic: true},
+		},
+		Usage:   "search <query>",
+		Handler: handleSearch,
+	})
+}
+
+func handleSearch(ctx context.Context, r *commandlib.Request) error {
+	query := strings.Join(messagelib.RemoveMentions(r.Args), " ")
+	if query == "" {
+		return commandlib.ErrUsage
+	}
+	results := quotelib.Suggest(ctx, query, quotelib.Stock, maxSearchResults)
+	messagelib.ReplyMessageComplex(r.Reply, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{messagelib.CreateSearchEmbed(query, results)},
+		Components: messagelib.CreateCommandButtons(quoteButtons(results)),
+	})
+	return nil
+}
+
+// suggestTickers returns tickers a ticker without a quote may have meant, e.g. "AAPL" for "APPL".
+func suggestTickers(ctx context.Context, rawTicker string, ticker string, class quotelib.AssetClass) []*messagelib.SearchResult {
+	var ret []*messagelib.SearchResult
+	for _, result := range quotelib.Suggest(ctx, ticker, class, maxSuggestions+1) {
+		if result.Ticker != rawTicker && len(ret) < maxSuggestions {
+			ret = append(ret, result)
+		}
+	}
+	return ret
+}
+
+// formatSuggestions returns a "did you mean" note for suggested tickers.
+func formatSuggestions(suggestions []*messagelib.SearchResult) string {
+	var names []string
+	for _, suggestion := range suggestions {
+		name := suggestion.Ticker
+		if suggestion.Name != "" {
+			name = fmt.Sprintf("%s (%s)", name, suggestion.Name)
+		}
+		names = append(names, name)
+	}
+	return fmt.Sprintf("Did you mean: %s?", strings.Join(names, ", "))
+}
+
+// quoteButtons returns a button quoting each of the tickers found.
+func quoteButtons(results []*messagelib.SearchResult) []*messagelib.CommandButton {
+	var buttons []*messagelib.CommandButton
+	for _, result := range results {
+		buttons = append(buttons, &messagelib.CommandButton{
+			Label:   result.Ticker,
+			Command: quoteCommandLine([]string{result.Ticker}, "", ""),
+		})
+	}
+	return buttons
+}
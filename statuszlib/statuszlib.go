@@ -7,7 +7,9 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/JoeParrinello/brokerbot/cachelib"
 	"github.com/JoeParrinello/brokerbot/cryptolib"
+	"github.com/JoeParrinello/brokerbot/ratelimitlib"
 )
 
 var (
@@ -29,6 +31,48 @@ const statuszTemplate string = `<h1>BrokerBot Statusz</h1>
 
 <p>error count: {{.ErrorCount}}</p>
 
+<p>caches:</p>
+
+<table>
+	<tr>
+		<td>Name</td>
+		<td>Size</td>
+		<td>Hits</td>
+		<td>Misses</td>
+		<td>Shared</td>
+	</tr>
+	{{ range .Caches }}
+		<tr>
+			<td>{{ .Name }}</td>
+			<td>{{ .Size }}</td>
+			<td>{{ .Hits }}</td>
+			<td>{{ .Misses }}</td>
+			<td>{{ .Shared }}</td>
+		</tr>
+	{{ end }}
+</table>
+
+<p>rate limiters:</p>
+
+<table>
+	<tr>
+		<td>Name</td>
+		<td>Queued</td>
+		<td>Calls</td>
+		<td>Throttled</td>
+		<td>Rejected</td>
+	</tr>
+	{{ range .RateLimiters }}
+		<tr>
+			<td>{{ .Name }}</td>
+			<td>{{ .Queued }}</td>
+			<td>{{ .Calls }}</td>
+			<td>{{ .Throttled }}</td>
+			<td>{{ .Rejected }}</td>
+		</tr>
+	{{ end }}
+</table>
+
 <p>crypto price feed last updated: {{.CryptoPriceFeedLastUpdated}}</p>
 
 <p>crypto price feed:</p>
@@ -60,6 +104,9 @@ type metrics struct {
 	SuccessCount int32
 	ErrorCount   int32
 
+	Caches       []cachelib.Stats
+	RateLimiters []ratelimitlib.Stats
+
 	CryptoPriceFeed            []*cryptolib.PriceFeed
 	CryptoPriceFeedLastUpdated time.Time
 }
@@ -78,6 +125,8 @@ func HandleStatusz(w http.ResponseWriter, r *http.Request) {
 	}
 	log.Println("Scraping metrics for /statusz")
 	statuszMetrics.Uptime = time.Since(startTime)
+	statuszMetrics.Caches = cachelib.GetStats()
+	statuszMetrics.RateLimiters = ratelimitlib.GetStats()
 	statuszMetrics.CryptoPriceFeed = cryptolib.GetLatestPriceFeed()
 	statuszMetrics.CryptoPriceFeedLastUpdated = cryptolib.GetLatestPriceFeedUpdateTime()
 
@@ -0,0 +1,113 @@
+package stocklib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Finnhub-Stock-API/finnhub-go"
+	"github.com/JoeParrinello/brokerbot/marketlib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/ratelimitlib"
+	"github.com/antihax/optional"
+)
+
+// earningsRelease is an entry of Finnhub's earnings calendar. The generated client leaves
+// entries as maps, and estimates may be fractional, so decode them into floats here.
+type earningsRelease struct {
+	Symbol          string   `json:"symbol"`
+	Date            string   `json:"date"`
+	Hour            string   `json:"hour"`
+	Year            int      `json:"year"`
+	Quarter         int      `json:"quarter"`
+	EpsEstimate     *float64 `json:"epsEstimate"`
+	EpsActual       *float64 `json:"epsActual"`
+	RevenueEstimate *float64 `json:"revenueEstimate"`
+}
+
+// GetEarningsCalendar returns the earnings releases between from and to, in date order.
+// If ticker is set, only its releases are returned.
+func GetEarningsCalendar(ctx context.Context, f *finnhub.DefaultApiService, from time.Time, to time.Time, ticker string) ([]*messagelib.EarningsEvent, error) {
+	opts := &finnhub.EarningsCalendarOpts{
+		From: optional.NewString(from.In(marketlib.Location()).Format("2006-01-02")),
+		To:   optional.NewString(to.In(marketlib.Location()).Format("2006-01-02")),
+	}
+	if ticker != "" {
+		opts.Symbol = optional.NewString(ticker)
+	}
+	var calendar finnhub.EarningsCalendar
+	err := ratelimitlib.Finnhub.Do(ctx, func() (res *http.Response, err error) {
+		calendar, res, err = f.EarningsCalendar(ctx, opts)
+		return res, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get earnings calendar: %v", err)
+	}
+
+	var events []*messagelib.EarningsEvent
+	for _, entry := range calendar.EarningsCalendar {
+		b, err := json.Marshal(entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal earnings release: %v", err)
+		}
+		var release earningsRelease
+		if err := json.Unmarshal(b, &release); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal earnings release: %v", err)
+		}
+		date, err := time.ParseInLocation("2006-01-02", release.Date, marketlib.Location())
+		if err != nil {
+			continue
+		}
+		events = append(events, &messagelib.EarningsEvent{
+			Ticker:          release.Symbol,
+			Date:            date,
+			Hour:            release.Hour,
+			Year:            release.Year,
+			Quarter:         release.Quarter,
+			EPSEstimate:     valueOrZero(release.EpsEstimate),
+			EPSActual:       valueOrZero(release.EpsActual),
+			RevenueEstimate: valueOrZero(release.RevenueEstimate),
+		})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Date.Before(events[j].Date)
+	})
+	return events, nil
+}
+
+// GetEarningsSurprises returns up to limit of the most recent reported quarters for the ticker, newest first.
+func GetEarningsSurprises(ctx context.Context, f *finnhub.DefaultApiService, ticker string, limit int) ([]*messagelib.EarningsSurprise, error) {
+	var results []finnhub.EarningResult
+	err := ratelimitlib.Finnhub.Do(ctx, func() (res *http.Response, err error) {
+		results, res, err = f.CompanyEarnings(ctx, ticker, &finnhub.CompanyEarningsOpts{
+			Limit: optional.NewInt64(int64(limit)),
+		})
+		return res, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get earnings surprises: %v", err)
+	}
+
+	surprises := make([]*messagelib.EarningsSurprise, 0, len(results))
+	for _, result := range results {
+		surprises = append(surprises, &messagelib.EarningsSurprise{
+			Period:   result.Period,
+			Actual:   float64(result.Actual),
+			Estimate: float64(result.Estimate),
+		})
+	}
+	sort.SliceStable(surprises, func(i, j int) bool {
+		return surprises[i].Period > surprises[j].Period
+	})
+	return surprises, nil
+}
+
+func valueOrZero(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
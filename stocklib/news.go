@@ -0,0 +1,80 @@
+package stocklib
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Finnhub-Stock-API/finnhub-go"
+	"github.com/JoeParrinello/brokerbot/cachelib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/ratelimitlib"
+)
+
+// News categories accepted by GetGeneralNews.
+const (
+	GeneralNews = "general"
+	CryptoNews  = "crypto"
+)
+
+// companyNewsLookback is how far back to search for company news.
+const companyNewsLookback = 7 * 24 * time.Hour
+
+var (
+	newsTTL   = flag.Duration("newsCacheTTL", 5*time.Minute, "How long to cache news headlines")
+	newsCache = cachelib.New("news", newsTTL)
+)
+
+// GetCompanyNews returns the latest news for the provided ticker, newest first.
+func GetCompanyNews(ctx context.Context, f *finnhub.DefaultApiService, ticker string) ([]*messagelib.NewsItem, error) {
+	news, err := newsCache.Get("company:"+ticker, func() (interface{}, error) {
+		now := time.Now()
+		var news []finnhub.News
+		err := ratelimitlib.Finnhub.Do(ctx, func() (res *http.Response, err error) {
+			news, res, err = f.CompanyNews(ctx, ticker, now.Add(-companyNewsLookback).Format("2006-01-02"), now.Format("2006-01-02"))
+			return res, err
+		})
+		return news, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newsItems(news.([]finnhub.News)), nil
+}
+
+// GetGeneralNews returns the latest market news in a category, newest first.
+func GetGeneralNews(ctx context.Context, f *finnhub.DefaultApiService, category string) ([]*messagelib.NewsItem, error) {
+	news, err := newsCache.Get("general:"+category, func() (interface{}, error) {
+		var news []finnhub.News
+		err := ratelimitlib.Finnhub.Do(ctx, func() (res *http.Response, err error) {
+			news, res, err = f.GeneralNews(ctx, category, &finnhub.GeneralNewsOpts{})
+			return res, err
+		})
+		return news, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newsItems(news.([]finnhub.News)), nil
+}
+
+func newsItems(news []finnhub.News) []*messagelib.NewsItem {
+	items := make([]*messagelib.NewsItem, 0, len(news))
+	for _, n := range news {
+		if n.Headline == "" || n.Url == "" {
+			continue
+		}
+		items = append(items, &messagelib.NewsItem{
+			Headline: n.Headline,
+			Source:   n.Source,
+			URL:      n.Url,
+			Time:     time.Unix(n.Datetime, 0),
+		})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Time.After(items[j].Time)
+	})
+	return items
+}
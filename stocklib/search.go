@@ -0,0 +1,99 @@
+package stocklib
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Finnhub-Stock-API/finnhub-go"
+	"github.com/JoeParrinello/brokerbot/cachelib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/ratelimitlib"
+)
+
+// The Finnhub client doesn't cover symbol lookup, so it's fetched from the API directly.
+const finnhubSearchURL = "https://finnhub.io/api/v1/search"
+
+var (
+	searchCache = cachelib.New("stock searches", cachelib.NameTTL)
+
+	searchClient = &http.Client{
+		Timeout: time.Second * 30,
+	}
+)
+
+// symbolLookup is the response of Finnhub's symbol lookup endpoint.
+type symbolLookup struct {
+	Result []struct {
+		Description   string `json:"description"`
+		DisplaySymbol string `json:"displaySymbol"`
+		Symbol        string `json:"symbol"`
+		Type          string `json:"type"`
+	} `json:"result"`
+}
+
+// Suggest implements quotelib.Suggester.
+func (p *FinnhubProvider) Suggest(ctx context.Context, query string) ([]*messagelib.SearchResult, error) {
+	return Search(ctx, query)
+}
+
+// Search returns US listed stocks and funds whose ticker or name matches the query, best match first.
+func Search(ctx context.Context, query string) ([]*messagelib.SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, nil
+	}
+	results, err := searchCache.Get(strings.ToUpper(query), func() (interface{}, error) {
+		return fetchSearch(ctx, query)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results.([]*messagelib.SearchResult), nil
+}
+
+func fetchSearch(ctx context.Context, query string) ([]*messagelib.SearchResult, error) {
+	apiKey, ok := ctx.Value(finnhub.ContextAPIKey).(finnhub.APIKey)
+	if !ok {
+		return nil, errors.New("no Finnhub API key in context")
+	}
+	values := url.Values{}
+	values.Set("q", query)
+	values.Set("exchange", "US")
+	values.Set("token", apiKey.Key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, finnhubSearchURL+"?"+values.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for symbol lookup: %v", err)
+	}
+
+	var lookup symbolLookup
+	err = ratelimitlib.Finnhub.Do(ctx, func() (*http.Response, error) {
+		res, err := searchClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return res, fmt.Errorf("symbol lookup for %q returned %s", query, res.Status)
+		}
+		return res, json.NewDecoder(res.Body).Decode(&lookup)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up symbol: %v", err)
+	}
+
+	results := make([]*messagelib.SearchResult, 0, len(lookup.Result))
+	for _, result := range lookup.Result {
+		results = append(results, &messagelib.SearchResult{
+			Ticker: result.DisplaySymbol,
+			Name:   result.Description,
+			Type:   result.Type,
+		})
+	}
+	return results, nil
+}
@@ -1,37 +1,107 @@
 package stocklib
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net/http"
-	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/Finnhub-Stock-API/finnhub-go"
+	"github.com/JoeParrinello/brokerbot/cachelib"
+	"github.com/JoeParrinello/brokerbot/marketlib"
 	"github.com/JoeParrinello/brokerbot/messagelib"
+	"github.com/JoeParrinello/brokerbot/quotelib"
+	"github.com/JoeParrinello/brokerbot/ratelimitlib"
 	"github.com/antihax/optional"
 )
 
+// stockResolution is how Finnhub candles are fetched for a timeframe.
+type stockResolution struct {
+	resolution string
+	// lookback overrides the timeframe duration so weekends and holidays don't leave short timeframes empty.
+	lookback time.Duration
+	// tradingDays, if set, keeps only the candles from the last tradingDays days with any trades.
+	tradingDays int
+}
+
+var stockResolutions = map[quotelib.Timeframe]stockResolution{
+	quotelib.OneDay:    {resolution: "5", lookback: 5 * 24 * time.Hour, tradingDays: 1},
+	quotelib.FiveDays:  {resolution: "15", lookback: 10 * 24 * time.Hour, tradingDays: 5},
+	quotelib.OneMonth:  {resolution: "60"},
+	quotelib.SixMonths: {resolution: "D"},
+	quotelib.OneYear:   {resolution: "D"},
+	quotelib.FiveYears: {resolution: "W"},
+}
+
+var (
+	// Finnhub only serves candles on paid plans, so on the free tier the lookup stops after the first denied request.
+	extendedHours = flag.Bool("stockExtendedHours", true, "Show the last trade time and pre/post market prices in stock quotes, from one minute candles")
+
+	quoteCache     = cachelib.New("stock quotes", cachelib.PriceTTL)
+	lastTradeCache = cachelib.New("stock last trades", cachelib.PriceTTL)
+	profileCache   = cachelib.New("stock profiles", cachelib.NameTTL)
+	metricsCache   = cachelib.New("stock metrics", cachelib.NameTTL)
+)
+
+// lastTradeLookback covers a long weekend, so the last trade before it can still be found.
+const lastTradeLookback = 4 * 24 * time.Hour
+
+// errNoCandleAccess is returned when the Finnhub API key's plan doesn't include candles.
+var errNoCandleAccess = errors.New("the Finnhub plan doesn't include stock candles")
+
+// candlesDenied is set once Finnhub denies a last trade lookup, so quotes stop asking for them.
+var candlesDenied int32
+
+// FinnhubProvider is a quotelib.QuoteProvider for stocks backed by the Finnhub API.
+type FinnhubProvider struct {
+	client *finnhub.DefaultApiService
+}
+
+// NewFinnhubProvider returns a FinnhubProvider using the given client.
+func NewFinnhubProvider(client *finnhub.DefaultApiService) *FinnhubProvider {
+	return &FinnhubProvider{client: client}
+}
+
+// GetQuote implements quotelib.QuoteProvider.
+func (p *FinnhubProvider) GetQuote(ctx context.Context, ticker string) (*messagelib.TickerValue, error) {
+	return GetQuoteForStockTicker(ctx, p.client, ticker)
+}
+
+// GetCandles implements quotelib.QuoteProvider.
+func (p *FinnhubProvider) GetCandles(ctx context.Context, ticker string, timeframe quotelib.Timeframe) ([]*quotelib.Candle, error) {
+	return GetCandlesForStockTicker(ctx, p.client, ticker, timeframe)
+}
+
+// GetName implements quotelib.QuoteProvider.
+func (p *FinnhubProvider) GetName(ctx context.Context, ticker string) (string, error) {
+	return GetNameForStockTicker(ctx, p.client, ticker)
+}
+
+// GetDetail implements quotelib.DetailProvider.
+func (p *FinnhubProvider) GetDetail(ctx context.Context, ticker string) (*messagelib.TickerDetail, error) {
+	return GetDetailForStockTicker(ctx, p.client, ticker)
+}
+
 // GetQuoteForStockTicker returns the TickerValue for the provided ticker
 func GetQuoteForStockTicker(ctx context.Context, f *finnhub.DefaultApiService, ticker string) (*messagelib.TickerValue, error) {
-	quote, _, err := f.Quote(ctx, ticker)
+	quote, err := getQuote(ctx, f, ticker)
 	if err != nil {
 		return nil, err
 	}
+	label := ticker
+	if symbol, ok := quotelib.LookupTicker(quotelib.Stock, ticker); ok {
+		label = symbol.Alias()
+	}
 	if quote.C == 0.0 {
 		// A value of 0.0 means that the Ticker is Undefined.
-		return &messagelib.TickerValue{Ticker: ticker, Value: 0.0, Change: 0.0}, nil
+		return &messagelib.TickerValue{Ticker: label, Value: 0.0, Change: 0.0}, nil
 	}
 	dailyChangePercent := ((quote.C - quote.Pc) / quote.Pc) * 100
-	company, _, err := f.CompanyProfile2(ctx, &finnhub.CompanyProfile2Opts{
-		Symbol: optional.NewString(ticker),
-	})
-	companyName := company.Name
+	companyName, err := GetNameForStockTicker(ctx, f, ticker)
 	if err != nil {
 		fmt.Printf("Company lookup failed, ignoring: %v", err)
 		companyName = "Error"
@@ -39,54 +109,249 @@ func GetQuoteForStockTicker(ctx context.Context, f *finnhub.DefaultApiService, t
 	if companyName == "" {
 		companyName = "Unknown"
 	}
-	return &messagelib.TickerValue{
-		Ticker: fmt.Sprintf("%s (%s)", ticker, companyName),
+	tickerValue := &messagelib.TickerValue{
+		Ticker: fmt.Sprintf("%s (%s)", label, companyName),
 		Value:  quote.C,
 		Change: dailyChangePercent,
-	}, nil
+	}
+	tickerValue.Session = marketlib.GetStatus(time.Now()).Describe()
+	if *extendedHours {
+		addExtendedHours(ctx, f, ticker, tickerValue)
+	}
+	return tickerValue, nil
 }
 
-func GetCandleGraphForStockAsset(ctx context.Context, f *finnhub.DefaultApiService, cloudRunClient *http.Client, ticker string) (string, error) {
-	now := time.Now()
-	candles, _, err := f.StockCandles(ctx, ticker, "15", now.Add(time.Hour*-24*7).Unix(), now.Unix(), &finnhub.StockCandlesOpts{})
+// addExtendedHours adds the last trade time and any pre or post market price to tickerValue.
+func addExtendedHours(ctx context.Context, f *finnhub.DefaultApiService, ticker string, tickerValue *messagelib.TickerValue) {
+	if atomic.LoadInt32(&candlesDenied) == 1 {
+		return
+	}
+	// Quotes only have the regular session price, but one minute candles include extended hours trades.
+	lastTrade, err := getLastTrade(ctx, f, ticker)
+	if errors.Is(err, errNoCandleAccess) {
+		if atomic.CompareAndSwapInt32(&candlesDenied, 0, 1) {
+			log.Printf("Turning off extended hours prices: %v", err)
+		}
+		return
+	}
+	if err != nil {
+		log.Printf("Last trade lookup failed, ignoring: %v", err)
+		return
+	}
+	if lastTrade == nil {
+		return
+	}
+	tickerValue.LastTrade = lastTrade.Time
+	session := marketlib.GetSession(lastTrade.Time)
+	if session != marketlib.PreMarket && session != marketlib.AfterHours {
+		return
+	}
+	tickerValue.ExtendedHours = &messagelib.ExtendedHoursValue{
+		Session: session.String(),
+		Value:   lastTrade.Close,
+		Change:  (lastTrade.Close - tickerValue.Value) / tickerValue.Value * 100,
+	}
+}
+
+// getLastTrade returns the latest one minute candle for the ticker, or nil if it hasn't traded recently.
+func getLastTrade(ctx context.Context, f *finnhub.DefaultApiService, ticker string) (*quotelib.Candle, error) {
+	lastTrade, err := lastTradeCache.Get(ticker, func() (interface{}, error) {
+		candles, err := fetchCandles(ctx, f, ticker, "1", lastTradeLookback)
+		if err != nil {
+			return nil, err
+		}
+		last := len(candles.T) - 1
+		if last < 0 || last >= len(candles.C) {
+			return (*quotelib.Candle)(nil), nil
+		}
+		return &quotelib.Candle{Time: time.Unix(candles.T[last], 0), Close: candles.C[last]}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lastTrade.(*quotelib.Candle), nil
+}
+
+func getQuote(ctx context.Context, f *finnhub.DefaultApiService, ticker string) (finnhub.Quote, error) {
+	quote, err := quoteCache.Get(ticker, func() (interface{}, error) {
+		var quote finnhub.Quote
+		err := ratelimitlib.Finnhub.Do(ctx, func() (res *http.Response, err error) {
+			quote, res, err = f.Quote(ctx, ticker)
+			return res, err
+		})
+		return quote, err
+	})
+	if err != nil {
+		return finnhub.Quote{}, err
+	}
+	return quote.(finnhub.Quote), nil
+}
+
+// GetNameForStockTicker returns the company name for the provided ticker, or "" if Finnhub doesn't know it.
+func GetNameForStockTicker(ctx context.Context, f *finnhub.DefaultApiService, ticker string) (string, error) {
+	if symbol, ok := quotelib.LookupTicker(quotelib.Stock, ticker); ok {
+		return symbol.Name, nil
+	}
+	profile, err := getProfile(ctx, f, ticker)
 	if err != nil {
-		log.Printf("failed to request stock candle: %v", err)
 		return "", err
 	}
+	return profile.Name, nil
+}
+
+func getProfile(ctx context.Context, f *finnhub.DefaultApiService, ticker string) (finnhub.CompanyProfile2, error) {
+	profile, err := profileCache.Get(ticker, func() (interface{}, error) {
+		var company finnhub.CompanyProfile2
+		err := ratelimitlib.Finnhub.Do(ctx, func() (res *http.Response, err error) {
+			company, res, err = f.CompanyProfile2(ctx, &finnhub.CompanyProfile2Opts{
+				Symbol: optional.NewString(ticker),
+			})
+			return res, err
+		})
+		return company, err
+	})
+	if err != nil {
+		return finnhub.CompanyProfile2{}, err
+	}
+	return profile.(finnhub.CompanyProfile2), nil
+}
 
-	marshalledReq, marshalError := json.Marshal(candles)
-	if marshalError != nil {
-		log.Printf("failed to marshal for stock candles graph: %v", marshalError)
-		return "", marshalError
+// GetDetailForStockTicker returns the quote, company profile and key metrics for the provided ticker.
+func GetDetailForStockTicker(ctx context.Context, f *finnhub.DefaultApiService, ticker string) (*messagelib.TickerDetail, error) {
+	tickerValue, err := GetQuoteForStockTicker(ctx, f, ticker)
+	if err != nil {
+		return nil, err
+	}
+	detail := &messagelib.TickerDetail{TickerValue: *tickerValue}
+	if tickerValue.Value == 0 {
+		return detail, nil
 	}
 
-	url := os.Getenv("GET_STOCK_CANDLE_GRAPH_URL")
-	if url == "" {
-		return "", errors.New("no stock candle graph url")
+	// The quote is cached, so this doesn't cost another call.
+	quote, err := getQuote(ctx, f, ticker)
+	if err != nil {
+		return nil, err
 	}
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(marshalledReq))
-	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	detail.Open, detail.High, detail.Low, detail.PreviousClose = quote.O, quote.H, quote.L, quote.Pc
 
-	res, cloudRunErr := cloudRunClient.Do(req)
-	if cloudRunErr != nil {
-		log.Printf("failed to execute request for stock candles graph: %v", cloudRunErr)
-		return "", cloudRunErr
+	if profile, err := getProfile(ctx, f, ticker); err != nil {
+		log.Printf("Company profile lookup failed, ignoring: %v", err)
+	} else {
+		// Finnhub reports market capitalization in millions.
+		detail.MarketCap = float64(profile.MarketCapitalization) * 1e6
+		detail.Industry = profile.FinnhubIndustry
+		detail.Logo = profile.Logo
+		detail.Website = profile.Weburl
 	}
 
-	if res.Body != nil {
-		defer res.Body.Close()
+	if metrics, err := getMetrics(ctx, f, ticker); err != nil {
+		log.Printf("Company metrics lookup failed, ignoring: %v", err)
+	} else {
+		detail.PERatio = metric(metrics, "peBasicExclExtraTTM", "peNormalizedAnnual")
+		detail.YearHigh = metric(metrics, "52WeekHigh")
+		detail.YearLow = metric(metrics, "52WeekLow")
 	}
 
-	if res.StatusCode != 200 {
-		log.Print("crypto candle image did not return")
-		return "", errors.New("crypto candle image did not return")
+	// Quotes don't include volume, so take it from today's candle.
+	if candles, err := fetchCandles(ctx, f, ticker, "D", lastTradeLookback); err != nil {
+		log.Printf("Volume lookup failed, ignoring: %v", err)
+	} else if len(candles.V) > 0 {
+		detail.Volume = float64(candles.V[len(candles.V)-1])
 	}
+	return detail, nil
+}
 
-	body, readErr := ioutil.ReadAll(res.Body)
-	if readErr != nil {
-		log.Printf("failed to read stock candles graph response: %v", readErr)
-		return "", readErr
+func getMetrics(ctx context.Context, f *finnhub.DefaultApiService, ticker string) (map[string]interface{}, error) {
+	metrics, err := metricsCache.Get(ticker, func() (interface{}, error) {
+		var financials finnhub.BasicFinancials
+		err := ratelimitlib.Finnhub.Do(ctx, func() (res *http.Response, err error) {
+			financials, res, err = f.CompanyBasicFinancials(ctx, ticker, "all")
+			return res, err
+		})
+		return financials.Metric, err
+	})
+	if err != nil {
+		return nil, err
 	}
+	return metrics.(map[string]interface{}), nil
+}
 
-	return string(body), nil
+// metric returns the first of the named metrics that Finnhub has a number for, or 0 if it has none.
+func metric(metrics map[string]interface{}, names ...string) float64 {
+	for _, name := range names {
+		if value, ok := metrics[name].(float64); ok {
+			return value
+		}
+	}
+	return 0
+}
+
+// GetCandlesForStockTicker returns candles covering the timeframe for the provided ticker.
+func GetCandlesForStockTicker(ctx context.Context, f *finnhub.DefaultApiService, ticker string, timeframe quotelib.Timeframe) ([]*quotelib.Candle, error) {
+	res, ok := stockResolutions[timeframe]
+	if !ok {
+		return nil, fmt.Errorf("unsupported stock timeframe: %q", timeframe.Name)
+	}
+	lookback := res.lookback
+	if lookback == 0 {
+		lookback = timeframe.Duration
+	}
+	candles, err := fetchCandles(ctx, f, ticker, res.resolution, lookback)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*quotelib.Candle, 0, len(candles.T))
+	for i, t := range candles.T {
+		if i >= len(candles.O) || i >= len(candles.H) || i >= len(candles.L) || i >= len(candles.C) || i >= len(candles.V) {
+			break
+		}
+		ret = append(ret, &quotelib.Candle{
+			Time:   time.Unix(t, 0),
+			Open:   candles.O[i],
+			High:   candles.H[i],
+			Low:    candles.L[i],
+			Close:  candles.C[i],
+			Volume: candles.V[i],
+		})
+	}
+	if res.tradingDays > 0 {
+		ret = lastTradingDays(ret, res.tradingDays)
+	}
+	return ret, nil
+}
+
+// lastTradingDays returns the candles from the last days dates that have candles.
+func lastTradingDays(candles []*quotelib.Candle, days int) []*quotelib.Candle {
+	seen := 0
+	for i := len(candles) - 1; i >= 0; i-- {
+		if i < len(candles)-1 && candles[i].Time.UTC().YearDay() == candles[i+1].Time.UTC().YearDay() {
+			continue
+		}
+		seen++
+		if seen > days {
+			return candles[i+1:]
+		}
+	}
+	return candles
+}
+
+func fetchCandles(ctx context.Context, f *finnhub.DefaultApiService, ticker string, resolution string, lookback time.Duration) (finnhub.StockCandles, error) {
+	now := time.Now()
+	var candles finnhub.StockCandles
+	var status int
+	err := ratelimitlib.Finnhub.Do(ctx, func() (res *http.Response, err error) {
+		candles, res, err = f.StockCandles(ctx, ticker, resolution, now.Add(-lookback).Unix(), now.Unix(), &finnhub.StockCandlesOpts{})
+		if res != nil {
+			status = res.StatusCode
+		}
+		return res, err
+	})
+	if status == http.StatusForbidden {
+		return finnhub.StockCandles{}, errNoCandleAccess
+	}
+	if err != nil {
+		log.Printf("failed to request stock candle: %v", err)
+		return finnhub.StockCandles{}, err
+	}
+	return candles, nil
 }
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/JoeParrinello/brokerbot/commandlib"
+	"github.com/JoeParrinello/brokerbot/firestorelib"
+	"github.com/JoeParrinello/brokerbot/messagelib"
+)
+
+func init() {
+	tickersArg := commandlib.Arg{Name: "tickers", Description: "Tickers, e.g. TSLA $ETH", Variadic: true, Complete: completeTicker}
+
+	commandlib.Register(&commandlib.Command{
+		Name:        "watch show",
+		Description: "Quote every ticker on your watchlist",
+		Usage:       "watch",
+		Implicit:    true,
+		Handler:     handleWatchShow,
+	})
+	commandlib.Register(&commandlib.Command{
+		Name:        "watch add",
+		Description: "Add tickers to your watchlist",
+		Args:        []commandlib.Arg{tickersArg},
+		Handler:     handleWatchAdd,
+	})
+	commandlib.Register(&commandlib.Command{
+		Name:        "watch remove",
+		Description: "Remove tickers from your watchlist",
+		Args:        []commandlib.Arg{tickersArg},
+		Handler:     handleWatchRemove,
+	})
+}
+
+func handleWatchShow(ctx context.Context, r *commandlib.Request) error {
+	watchlist, err := firestorelib.GetWatchlist(ctx, r.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get watchlist: %v", err)
+	}
+	if len(watchlist) == 0 {
+		messagelib.ReplyMessage(r.Reply, fmt.Sprintf("Your watchlist is empty, add to it with: %s watch add <ticker> <ticker> ...", botPrefixes[0]))
+		return nil
+	}
+	return replyWithQuotes(ctx, r, watchlist)
+}
+
+func handleWatchAdd(ctx context.Context, r *commandlib.Request) error {
+	tickers := messagelib.CanonicalizeMessage(r.Args)
+	if err := firestorelib.AddToWatchlist(ctx, r.UserID, tickers); err != nil {
+		return err
+	}
+	messagelib.ReplyMessage(r.Reply, fmt.Sprintf("Added to watchlist: %s", strings.Join(tickers, ", ")))
+	return nil
+}
+
+func handleWatchRemove(ctx context.Context, r *commandlib.Request) error {
+	tickers := messagelib.CanonicalizeMessage(r.Args)
+	if err := firestorelib.RemoveFromWatchlist(ctx, r.UserID, tickers); err != nil {
+		return err
+	}
+	messagelib.ReplyMessage(r.Reply, fmt.Sprintf("Removed from watchlist: %s", strings.Join(tickers, ", ")))
+	return nil
+}